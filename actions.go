@@ -3,289 +3,464 @@ package world
 import (
 	"fmt"
 	"math/rand"
+	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
-var (
-	actions = []action{"move", "skip", "look"}
-	wg      sync.WaitGroup
-)
-
-// Action describes what an exister can do on a given turn
-type action string
-type priority int32
-
-type priorityAction struct {
-	p priority
-	a action
-	f func()
+// Action is something an exister can do on its turn.
+// Every turn each exister does the registered action with the highest score, see RegisterAction.
+type Action interface {
+	// Name identifies the action in the registry
+	Name() string
+	// Score returns how much e wants to do the action right now, the highest score wins; see Utility.
+	// It is called for all existers at once from many goroutines and must only read the world,
+	// use World.TurnRandom for random decisions.
+	Score(w *World, e Exister) Score
+	// Execute does the action, one exister at a time, see doActions.
+	// An error means the action could not be done and the exister wasted its turn.
+	Execute(w *World, e Exister) error
 }
 
-// doActions dispatches an action to each peep.
-func (w *World) doActions() {
-	var doneID []string // already processed
-
-	for _, l := range w.allLocations() {
-
-		e := w.LocationExister(l)
+// defaultActions returns the actions every world with these settings starts with
+func defaultActions(s Settings) []Action {
+	actions := []Action{&moveAction{}, skipAction{}, lookAction{}}
+	if s.foodEnabled() {
+		actions = append(actions, eatAction{})
+	}
+	return actions
+}
 
-		if e == nil || ListContainsString(doneID, e.ID()) {
-			continue // empty spot
+// RegisterAction adds an action existers can choose from on their turn.
+// An action with the same name is replaced in place, so the built-in "move", "look" and "skip" can be overridden.
+// When actions have the same score, the one registered first wins.
+// Register actions before running the world or between turns.
+func (w *World) RegisterAction(a Action) {
+	for i, old := range w.actions {
+		if old.Name() == a.Name() {
+			w.actions[i] = a
+			return
 		}
+	}
+	w.actions = append(w.actions, a)
+}
 
-		// get the best action for this Exister right now
-		action := w.bestAction(e)
-		// and run it
-		action()
-		doneID = append(doneID, e.ID())
+// UnregisterAction removes the action with name, if registered
+func (w *World) UnregisterAction(name string) {
+	for i, a := range w.actions {
+		if a.Name() == name {
+			w.actions = append(w.actions[:i:i], w.actions[i+1:]...)
+			return
+		}
 	}
+}
 
+// Actions returns the registered actions, in the order they were registered
+func (w *World) Actions() []Action {
+	return append([]Action(nil), w.actions...)
 }
 
-// bestAction returns a closure that executes the best action for a peep
-func (w *World) bestAction(e Exister) func() {
-	c := make(chan priorityAction, 5)
+// intent is what an exister decided to do this turn, see doActions
+type intent struct {
+	e      Exister
+	order  uint64  // position in the resolve order
+	a      Action  // nil if there is nothing to do
+	scores []Score // of all actions, best first
+}
 
-	for _, a := range actions {
-		wg.Add(1)
-		go w.actionPriority(a, e, c)
+// doActions runs the actions of one turn in two phases.
+// First every living exister on the grid decides what to do, in parallel, looking at the world as it was at the start of the turn.
+// Then the decisions are applied one at a time, in an order drawn from the seed and the turn:
+// if two existers want the same cell, the first one gets it and the other one bumps into it.
+// Neither phase depends on how many goroutines run, so the result is the same for any GOMAXPROCS.
+func (w *World) doActions() {
+	var existers []Exister
+	for _, e := range w.allExisters() {
+		if _, ok := e.(Living); ok { // rocks and buildings don't act
+			existers = append(existers, e)
+		}
 	}
+	intents := make([]intent, len(existers))
+	w.paths.prune()
+
+	parallel(len(existers), func(i int) {
+		e := existers[i]
+		intents[i] = intent{
+			e:      e,
+			order:  w.turnHash(e.ID(), "order"),
+			scores: w.scoreActions(e),
+		}
+		if len(intents[i].scores) > 0 {
+			intents[i].a = w.action(intents[i].scores[0].Action)
+		}
+	})
 
-	// this waits for all channels to return something and then closes the channel
-	go func() {
-		wg.Wait()
-		close(c)
-	}()
-
-	var bestAction func()
-	var highestPriority priority
+	w.decisions = make(map[string][]Score, len(intents))
+	for _, in := range intents {
+		w.decisions[in.e.ID()] = in.scores
+	}
 
-	// get results from all goroutines, keep only the one with max priority
-	// this ranges over c until the goroutine above closes c
-	for pa := range c {
-		if pa.p > highestPriority {
-			highestPriority = pa.p
-			bestAction = pa.f
+	sort.Slice(intents, func(i, j int) bool {
+		if intents[i].order != intents[j].order {
+			return intents[i].order < intents[j].order
 		}
+		return intents[i].e.ID() < intents[j].e.ID()
+	})
 
+	for _, in := range intents {
+		if in.a == nil {
+			continue
+		}
+		if _, err := w.ExisterLocation(in.e); err != nil {
+			continue // taken off the grid this turn
+		}
+		in.a.Execute(w, in.e)
 	}
-	return bestAction
 }
 
-// lookAction returns a priority and the look action
-// this trumps moveAction if the peep hasn't looked around recently
-func (w *World) lookAction(e Exister) (priority, func()) {
-	// Before moving, look around to see what's out there
-	action := func() {
-		w.LookAround(e)
+// parallel calls f(0) ... f(n-1) on all available cores and waits for them to finish
+func parallel(n int, f func(i int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
 	}
-	pr := priority(10)
-
-	if w.turn-e.LookTurn() < w.settings.PeepRememberTurns {
-		pr = priority(1) // peeps still remembers the last time it looked
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
 	}
 
-	return pr, action
-}
-
-// moveAction returns the move action
-func (w *World) moveAction(e Exister) (priority, func()) {
-	action := func() {
-		w.movePeep(e)
+	var wg sync.WaitGroup
+	next := int64(-1)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				f(i)
+			}
+		}()
 	}
-	return 5, action
+	wg.Wait()
 }
 
-// skipAction returns the skip action
-func (w *World) skipAction(e Exister) (priority, func()) {
-	action := func() {
-		return
+// action returns the registered action with name
+func (w *World) action(name string) Action {
+	for _, a := range w.actions {
+		if a.Name() == name {
+			return a
+		}
 	}
-	return 0, action
+	return nil
 }
 
-// LookAround tells the Exister to look around and record what's around where
-// Existers remember what they saw for 4 turns (configrable)
-func (w *World) LookAround(e Exister) {
-	e.SetNeighbors()
-	e.SetLookTurn(w.turn)
-}
+// lookAction makes a peep look around.
+// This trumps moveAction if the peep hasn't looked around recently.
+type lookAction struct{}
 
-// actionPriority returns a priority and the closure for the given action
-// A higher priority will get executed first.
-func (w *World) actionPriority(a action, e Exister, c chan priorityAction) {
-	defer wg.Done()
+// Name implements Action
+func (lookAction) Name() string { return "look" }
 
-	var p priority
-	f := func() { return } // default is do nothing
+// Score implements Action, peeps look around once they forget what they saw
+func (lookAction) Score(w *World, e Exister) Score {
+	return Utility(w, e, 1, staleMemory)
+}
 
-	switch a {
-	case "move":
-		p, f = w.moveAction(e)
+// Execute implements Action
+func (lookAction) Execute(w *World, e Exister) error {
+	w.LookAround(e)
+	return nil
+}
 
-	case "look":
-		p, f = w.lookAction(e)
+// moveAction moves a peep one step towards where it wants to go.
+// The step is decided in Score, from the world as it was at the start of the turn, and taken in Execute.
+type moveAction struct {
+	lock  sync.Mutex
+	turn  Turn
+	steps map[Exister]Location // decided this turn, X, Y and Z are magnitudes
+}
 
-	case "skip":
-		p, f = w.skipAction(e)
+// Name implements Action
+func (m *moveAction) Name() string { return "move" }
 
-	default:
+// Score implements Action.
+// Peeps are keener to move the more they saw around them, when they are ready to spawn and the further they are from home.
+// Predators are keener when they saw prey.
+func (m *moveAction) Score(w *World, e Exister) Score {
+	x, y, z, err := w.decideMove(e, w.TurnRandom(e, m.Name()))
+	if err != nil {
+		return Score{Considerations: []Rating{{"can_move", 0}}}
+	}
 
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.steps == nil || m.turn != w.turn {
+		m.steps = make(map[Exister]Location)
+		m.turn = w.turn
 	}
-	c <- priorityAction{p, a, f}
+	m.steps[e] = Location{x, y, z}
+	if isPredator(e) {
+		return Utility(w, e, 0.8, fast, preyInSight)
+	}
+	return Utility(w, e, 0.8, fast, neighborsSeen, readyToSpawn, awayFromHome)
 }
 
-// movePeeps moves a single peep to the best location
-func (w *World) movePeep(e Exister) error {
-	if !allowMoves {
-		return fmt.Errorf("Moves not allowed by config.")
+// Execute implements Action
+func (m *moveAction) Execute(w *World, e Exister) error {
+	m.lock.Lock()
+	step, ok := m.steps[e]
+	if m.turn != w.turn {
+		ok = false
 	}
+	m.lock.Unlock()
 
-	// Dead peeps don't move... for now.
-	if !e.IsAlive() {
-		return fmt.Errorf("Dead peeps don't move!")
+	if !ok {
+		// not decided this turn, decide now
+		x, y, z, err := w.decideMove(e, w.TurnRandom(e, m.Name()))
+		if err != nil {
+			return err
+		}
+		step = Location{x, y, z}
 	}
 
-	// Look around first
 	w.LookAround(e)
+	return w.Move(e, step.X, step.Y, step.Z)
+}
 
-	x, y, z := w.BestPeepMove(e)
+// skipAction does nothing, old peeps like it more
+type skipAction struct{}
 
-	if err := w.Move(e, x, y, z); err != nil {
-		return fmt.Errorf("Error moving peep: %v", err)
-	}
+// Name implements Action
+func (skipAction) Name() string { return "skip" }
 
-	return nil
+// Score implements Action
+func (skipAction) Score(w *World, e Exister) Score { return Utility(w, e, 0.25, oldAge) }
+
+// Execute implements Action
+func (skipAction) Execute(w *World, e Exister) error { return nil }
+
+// LookAround tells the Exister to look around and record what's around where, if it is a Perceiver
+// Existers remember what they saw for 4 turns (configrable)
+func (w *World) LookAround(e Exister) {
+	if p, ok := e.(Perceiver); ok {
+		p.SetNeighbors()
+		p.SetLookTurn(w.turn)
+	}
 }
 
-// NextMoveToGetFromTo returns the x, y, z magnitude in order to move from src to dst
-func (w *World) NextMoveToGetFromTo(src, dst Location) (x int32, y int32, z int32) {
-	if src.SameAs(dst) {
-		return 0, 0, 0
+// decideMove returns where a single peep or predator wants to move, judging from what it sees right now
+func (w *World) decideMove(e Exister, rng *rand.Rand) (x, y, z int32, err error) {
+	if !allowMoves {
+		return 0, 0, 0, fmt.Errorf("Moves not allowed by config.")
 	}
 
-	if dst.X > src.X {
-		x = 1
-	} else if dst.X < src.X {
-		x = -1
-	} else {
-		x = 0
+	// Dead peeps don't move... for now. Nor do things that never lived.
+	if !isAlive(e) {
+		return 0, 0, 0, fmt.Errorf("Dead peeps don't move!")
 	}
 
-	if dst.Y > src.Y {
-		y = 1
-	} else if dst.Y < src.Y {
-		y = -1
+	if isPredator(e) {
+		x, y, z = w.bestPredatorMove(e, w.visibleNeighbors(e), rng)
 	} else {
-		y = 0
+		x, y, z = w.bestPeepMove(e, w.visibleNeighbors(e), rng)
 	}
+	return x, y, z, nil
+}
 
-	if dst.Z > src.Z {
-		z = 1
-	} else if dst.Z < src.Z {
-		z = -1
-	} else {
-		z = 0
+// visibleNeighbors returns the alive existers e can see right now, see Peep.SetNeighbors
+func (w *World) visibleNeighbors(e Exister) map[Location]Exister {
+	neighbors := make(map[Location]Exister)
+	for _, n := range w.existersAround(e.Location(), viewDistance(e)) {
+		if isAlive(n) {
+			neighbors[n.Location()] = n
+		}
 	}
+	return neighbors
+}
+
+// NextMoveToGetFromTo returns the x, y, z magnitude in order to move from src to dst
+func (w *World) NextMoveToGetFromTo(src, dst Location) (x int32, y int32, z int32) {
+	return w.nextMoveToGetFromTo(src, dst, w.random)
+}
+
+// nextMoveToGetFromTo is NextMoveToGetFromTo drawing random moves from rng
+func (w *World) nextMoveToGetFromTo(src, dst Location, rng *rand.Rand) (x int32, y int32, z int32) {
+	if src.SameAs(dst) {
+		return 0, 0, 0
+	}
+
+	// straight at dst, the short way round if the edges join
+	x, y, z = w.settings.offset(src, dst)
+	x, y, z = sign(x), sign(y), sign(z)
 
 	// check if the suggested square is busy and try alternatives
-	if w.IsOccupiedLocation(Location{src.X + x, src.Y + y, src.Z + z}) {
-		if !w.IsOccupiedLocation(Location{src.X, src.Y + y, src.Z + z}) &&
-			!w.IsOutsideGrid(src.X, src.Y+y, src.Z+z) {
+	if w.stepTaken(src, x, y, z) {
+		if !w.stepTaken(src, 0, y, z) {
 			return 0, y, z
 		}
-		if !w.IsOccupiedLocation(Location{src.X + x, src.Y, src.Z + z}) &&
-			!w.IsOutsideGrid(src.X+x, src.Y, src.Z+z) {
+		if !w.stepTaken(src, x, 0, z) {
 			return x, 0, z
 		}
-		if !w.IsOccupiedLocation(Location{src.X + x, src.Y + y, src.Z}) &&
-			!w.IsOutsideGrid(src.X+x, src.Y+y, src.Z) {
+		if !w.stepTaken(src, x, y, 0) {
 			return x, y, 0
 		}
 		// Random
-		return w.randomMove()
+		return randomMove(rng)
 	}
 	return x, y, z
 }
 
+// stepTaken returns true if one step of x, y, z magnitude from l leads to an occupied or impassable cell
+func (w *World) stepTaken(l Location, x, y, z int32) bool {
+	to := w.step(l, x, y, z)
+	return w.IsOccupiedLocation(to) || w.IsBlocked(to.X, to.Y, to.Z)
+}
+
 // NextMoveToGetAwayFrom returns the x, y, z magnitude in order to move away from loc while at current
 func (w *World) NextMoveToGetAwayFrom(current, loc Location) (x int32, y int32, z int32) {
+	return w.nextMoveToGetAwayFrom(current, loc, w.random)
+}
 
-	if loc.X >= 0 {
-		x = -1
-	} else {
-		x = 1
+// nextMoveToGetAwayFrom is NextMoveToGetAwayFrom drawing random moves from rng
+func (w *World) nextMoveToGetAwayFrom(current, loc Location, rng *rand.Rand) (x int32, y int32, z int32) {
+	if current.SameAs(loc) {
+		return randomMove(rng)
 	}
 
-	if loc.Y >= 0 {
-		y = -1
-	} else {
-		y = 1
-	}
+	// straight away from loc, the short way round if the edges join
+	x, y, z = w.settings.offset(loc, current)
+	x, y, z = sign(x), sign(y), sign(z)
 
 	// check if the suggested square is busy and try alternatives
-	if w.IsOccupiedLocation(Location{current.X + x, current.Y + y, current.Z + z}) ||
-		w.IsOutsideGrid(current.X+x, current.Y+y, current.Z+z) {
-		if !w.IsOccupiedLocation(Location{current.X, current.Y + y, current.Z + z}) &&
-			!w.IsOutsideGrid(current.X, current.Y+y, current.Z+z) {
+	if w.stepTaken(current, x, y, z) {
+		if !w.stepTaken(current, 0, y, z) {
 			return 0, y, z
 		}
-		if !w.IsOccupiedLocation(Location{current.X + x, current.Y, current.Z + z}) &&
-			!w.IsOutsideGrid(current.X+x, current.Y, current.Z+z) {
+		if !w.stepTaken(current, x, 0, z) {
 			return x, 0, z
 		}
-		if !w.IsOccupiedLocation(Location{current.X + x, current.Y + y, current.Z}) &&
-			!w.IsOutsideGrid(current.X+x, current.Y+y, current.Z) {
+		if !w.stepTaken(current, x, y, 0) {
 			return x, y, 0
 		}
 		// Random
-		return w.randomMove()
+		return randomMove(rng)
 	}
 	return x, y, z
 }
 
-// BestPeepMove returns the most optimal move for a peep
+// BestPeepMove returns the most optimal move for a peep, based on the neighbors it saw when it last looked around
 // x, y and z are magnitudes, not coordinates.
 func (w *World) BestPeepMove(e Exister) (x int32, y int32, z int32) {
-	// Suggest move based on neighbors around
-	neighbors := e.NeighborsFromLook()
+	var neighbors map[Location]Exister
+	if p, ok := e.(Perceiver); ok {
+		neighbors = p.NeighborsFromLook()
+	}
+	return w.bestPeepMove(e, neighbors, w.random)
+}
+
+// bestPeepMove returns the most optimal move for a peep seeing neighbors, drawing random moves from rng
+func (w *World) bestPeepMove(e Exister, neighbors map[Location]Exister, rng *rand.Rand) (x int32, y int32, z int32) {
+	// Visit neighbors in a stable order so the same seed always yields the same move
+	locations := make([]Location, 0, len(neighbors))
+	for l := range neighbors {
+		locations = append(locations, l)
+	}
+	SortLocations(locations)
+
+	// Peeps run from the closest predator they can see
+	if p := w.nearestOf(e.Location(), neighbors, isPredator); p != nil {
+		return w.nextMoveToGetAwayFrom(e.Location(), p.Location(), rng)
+	}
+
+	// Hungry peeps go for the closest food they can see
+	if w.hungry(e) {
+		if f := w.nearestFood(e.Location(), viewDistance(e)); f != nil && w.distance(e.Location(), f.Location) > 1 {
+			return w.nextStepTo(e, f.Location, rng)
+		}
+	}
+
+	// Peeps that don't feel sociable ignore the others this time
+	if len(locations) > 0 && !sociable(e, rng) {
+		return w.wanderMove(e, rng)
+	}
 
-	for _, n := range neighbors {
+	self, ok := e.(mate)
+	if !ok {
+		return w.wanderMove(e, rng) // nobody to mate with
+	}
+	for _, l := range locations {
+		n, ok := neighbors[l].(mate)
+		if !ok {
+			continue
+		}
 		// Move towards same gender if have not yet spawned and are both of spawn age
-		if n.Gender() == e.Gender() {
-			if w.turn - n.SpawnTurn() < w.settings.PeepSpawnInterval {
-				Log("too recent spawn", n.SpawnTurn())
+		if n.Gender() == self.Gender() {
+			if w.turn-n.SpawnTurn() < w.settings.PeepSpawnInterval {
 				continue // spawned too recently
 			}
 
-			if e.MetPeep(n) {
+			if self.MetPeep(n) {
 				continue // already met
 			}
 
 			if w.OfSpawnAge(e) && w.OfSpawnAge(n) {
-				return w.NextMoveToGetFromTo(e.Location(), n.Location())
+				return w.nextStepTo(e, n.Location(), rng)
 			}
 		} else { // different genders
 			// Move towards different gender peep
-			return w.NextMoveToGetFromTo(e.Location(), n.Location())
+			return w.nextStepTo(e, n.Location(), rng)
 		}
 	}
 
-	return w.randomMove()
-
-	// Possibilities
 	// No interesting neighbors around
-	if w.OfSpawnAge(e) {
-		// Move towards base
-		return w.NextMoveToGetFromTo(e.Location(), e.Homebase())
-	} else {
-		// Move away from base
-		return w.NextMoveToGetAwayFrom(e.Location(), e.Homebase())
+	return w.wanderMove(e, rng)
+}
+
+// wanderMove returns where a peep with no mate in sight goes, following Settings.MovementPolicy
+func (w *World) wanderMove(e Exister, rng *rand.Rand) (x int32, y int32, z int32) {
+	s, social := e.(Social)
+	if w.settings.MovementPolicy != MovementLifecycle || !social {
+		return randomMove(rng)
 	}
 
+	l, home := e.Location(), s.Homebase()
+	switch {
+	case !w.OfSpawnAge(e):
+		// Young peeps move away from base
+		return w.nextMoveToGetAwayFrom(l, home, rng)
+	case age(e) < w.elderAge(e):
+		// Adults move towards base, and stay around it once there
+		if w.distance(l, home) <= 1 {
+			return randomMove(rng)
+		}
+		// the base itself is off limits, aim for the cell next to it on this side
+		x, y, z := w.settings.offset(home, l)
+		return w.nextStepTo(e, w.step(home, sign(x), sign(y), sign(z)), rng)
+	default:
+		// Elders wander
+		return randomMove(rng)
+	}
+}
+
+// sign returns -1, 0 or 1 depending on the sign of v
+func sign(v int32) int32 {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
 }
 
-func (w *World) randomMove() (x, y, z int32) {
+// randomMove returns a random step on the same level
+func randomMove(rng *rand.Rand) (x, y, z int32) {
 	m := []int32{-1, 0, 1}
-	return m[rand.Intn(len(m))], m[rand.Intn(len(m))], z
+	return m[rng.Intn(len(m))], m[rng.Intn(len(m))], z
 }
@@ -1,6 +1,7 @@
 package world
 
 import (
+	"runtime"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -78,4 +79,196 @@ func TestNextMoveToGetAwayFrom(t *testing.T) {
 		So(y, ShouldEqual, 1)
 		So(z, ShouldEqual, 0)
 	})
+
+	Convey("Directions are relative to the current location, not the origin", t, func() {
+		x, y, z := w.NextMoveToGetAwayFrom(Location{5, 5, 0}, Location{7, 5, 0})
+		So([]int32{x, y, z}, ShouldResemble, []int32{-1, 0, 0})
+
+		x, y, z = w.NextMoveToGetAwayFrom(Location{-5, -5, 0}, Location{-7, -3, 0})
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, -1, 0})
+	})
+
+	Convey("Peeps against the border slide along it", t, func() {
+		x, y, z := w.NextMoveToGetAwayFrom(Location{9, 3, 0}, Location{5, 1, 0})
+		So([]int32{x, y, z}, ShouldResemble, []int32{0, 1, 0})
+	})
+}
+
+func TestWanderMove(t *testing.T) {
+	w := genWorld()
+	w.SetHomebase("red", Location{0, 0, 0})
+	w.settings.MaxAge = 40
+	w.settings.SpawnAge = 10
+	w.settings.MovementPolicy = MovementLifecycle
+
+	young, _ := w.spawnPeep("red", &Location{2, 3, 0}, 5, nil)
+	adult, _ := w.spawnPeep("red", &Location{-4, 4, 0}, 20, nil)
+	elder, _ := w.spawnPeep("red", &Location{-4, -4, 0}, 35, nil)
+
+	Convey("Young peeps move away from their homebase.", t, func() {
+		x, y, z := w.wanderMove(young, w.random)
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, 1, 0})
+	})
+
+	Convey("Adults head back to their homebase, and stay around it.", t, func() {
+		x, y, z := w.wanderMove(adult, w.random)
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, -1, 0})
+
+		w.grid.Set(adult, Location{1, 1, 0})
+		for i := 0; i < 20; i++ {
+			x, y, _ := w.wanderMove(adult, w.random)
+			So(chebyshev(Location{1 + x, 1 + y, 0}, Location{0, 0, 0}), ShouldBeLessThanOrEqualTo, 2)
+		}
+	})
+
+	Convey("Elders wander.", t, func() {
+		moves := map[[2]int32]bool{}
+		for i := 0; i < 50; i++ {
+			x, y, _ := w.wanderMove(elder, w.random)
+			moves[[2]int32{x, y}] = true
+		}
+		So(len(moves), ShouldBeGreaterThan, 4)
+
+		w.settings.ElderAge = 36
+		x, y, z := w.wanderMove(elder, w.random)
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, 1, 0}) // an adult again
+	})
+
+	Convey("The random policy ignores the homebase.", t, func() {
+		w.settings.MovementPolicy = MovementRandom
+		moves := map[[2]int32]bool{}
+		for i := 0; i < 50; i++ {
+			x, y, _ := w.wanderMove(young, w.random)
+			moves[[2]int32{x, y}] = true
+		}
+		So(len(moves), ShouldBeGreaterThan, 4)
+	})
+}
+
+func TestLifecycleMovement(t *testing.T) {
+	w := genWorld()
+	enableMoves(t)
+	w.settings.MovementPolicy = MovementLifecycle
+	w.settings.PeepRememberTurns = 100
+	w.SetHomebase("red", Location{0, 0, 0})
+
+	young, _ := w.spawnPeep("red", &Location{1, 1, 0}, 0, nil)
+	w.LookAround(young)
+	for i := 0; i < 5; i++ {
+		w.turn++
+		w.doActions()
+	}
+
+	Convey("A young peep on its own walks away from home.", t, func() {
+		So(young.Location(), ShouldResemble, Location{6, 6, 0})
+	})
+}
+
+func TestDoActionsSameOnAnyCores(t *testing.T) {
+	enableMoves(t)
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+
+	runtime.GOMAXPROCS(1)
+	serial := turnHistory(genSeededWorld(42), 50)
+	runtime.GOMAXPROCS(8)
+	parallel := turnHistory(genSeededWorld(42), 50)
+
+	Convey("Turns play out the same no matter how many cores run them.", t, func() {
+		So(parallel, ShouldResemble, serial)
+	})
+}
+
+func TestDoActionsContestedSquare(t *testing.T) {
+	w := genWorld()
+	enableMoves(t)
+	w.settings.PeepRememberTurns = 5
+	r := &eventRecorder{}
+	w.AddEventSink(r)
+
+	// both want {4, 3, 0}
+	left, _ := w.NewPeep("red", Location{3, 3, 0})
+	right, _ := w.NewPeep("blue", Location{5, 3, 0})
+	w.LookAround(left)
+	w.LookAround(right)
+	w.doActions()
+
+	Convey("Only one peep gets a contested square, the other one bumps into it.", t, func() {
+		moved := r.ofType(EventMove)
+		So(len(moved), ShouldEqual, 1)
+		So(*moved[0].Location, ShouldResemble, Location{4, 3, 0})
+
+		winner, loser := left, right
+		if moved[0].ID == right.ID() {
+			winner, loser = right, left
+		}
+		So(winner.Location(), ShouldResemble, Location{4, 3, 0})
+		So(loser.MetPeep(winner), ShouldBeTrue)
+
+		met := r.ofType(EventMeet)
+		So(len(met), ShouldEqual, 1)
+		So(met[0].ID, ShouldEqual, loser.ID())
+		So(met[0].Other, ShouldEqual, winner.ID())
+	})
+}
+
+// sleepAction makes young peeps sleep through their turn
+type sleepAction struct {
+	slept []string
+}
+
+func (a *sleepAction) Name() string { return "sleep" }
+
+func (a *sleepAction) Score(w *World, e Exister) Score {
+	return Utility(w, e, 1, Consideration{"young", func(w *World, e Exister) float64 {
+		if l, ok := e.(Living); ok && l.Age() < 2 {
+			return 1
+		}
+		return 0
+	}})
+}
+
+func (a *sleepAction) Execute(w *World, e Exister) error {
+	a.slept = append(a.slept, e.ID())
+	return nil
+}
+
+func TestRegisterAction(t *testing.T) {
+	w := genWorld()
+	young, _ := w.spawnPeep("red", &Location{1, 1, 0}, 1, nil)
+	old, _ := w.spawnPeep("red", &Location{5, 5, 0}, 3, nil)
+	w.turn = 3
+	w.settings.PeepRememberTurns = 5
+
+	Convey("Worlds start with the built-in actions.", t, func() {
+		var names []string
+		for _, a := range w.Actions() {
+			names = append(names, a.Name())
+		}
+		So(names, ShouldResemble, []string{"move", "skip", "look"})
+	})
+
+	Convey("Registered actions are done when they have the highest score.", t, func() {
+		sleep := &sleepAction{}
+		w.RegisterAction(sleep)
+		So(len(w.Actions()), ShouldEqual, 4)
+
+		w.doActions()
+		So(sleep.slept, ShouldResemble, []string{young.ID()})
+		So(young.LookTurn(), ShouldEqual, 0)
+		So(old.LookTurn(), ShouldEqual, 3) // looked around instead
+	})
+
+	Convey("Actions with the same name are replaced in place.", t, func() {
+		sleep := &sleepAction{}
+		w.RegisterAction(sleep)
+		So(len(w.Actions()), ShouldEqual, 4)
+		So(w.Actions()[3], ShouldEqual, sleep)
+	})
+
+	Convey("Actions can be unregistered.", t, func() {
+		w.UnregisterAction("sleep")
+		w.UnregisterAction("look")
+		So(len(w.Actions()), ShouldEqual, 2)
+		So(w.Actions()[1].Name(), ShouldEqual, "skip")
+	})
 }
@@ -0,0 +1,270 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultPageSize = 100  // peeps per page if no limit is given
+	maxPageSize     = 1000 // max peeps per page
+)
+
+// AgeInfo describes the ages of alive peeps
+type AgeInfo struct {
+	Max PeepAge `json:"max"`
+	Avg PeepAge `json:"avg"`
+	Min PeepAge `json:"min"`
+}
+
+// WorldInfo is the state of the world returned by /api/world
+type WorldInfo struct {
+	Name      string                  `json:"name"`
+	Turn      Turn                    `json:"turn"`
+	Alive     int64                   `json:"alive"`
+	MaxPeeps  int64                   `json:"max_peeps"`
+	Ages      AgeInfo                 `json:"ages"`
+	Genome    GenomeStats             `json:"genome"` // average of the alive peeps
+	Genders   map[PeepGender]int64    `json:"genders"`
+	Predators int64                   `json:"predators"` // alive predators
+	Homebases map[PeepGender]Location `json:"homebases"`
+}
+
+// PeepInfo describes a peep
+type PeepInfo struct {
+	ID         string     `json:"id"`
+	Gender     PeepGender `json:"gender"`
+	Age        PeepAge    `json:"age"`
+	Alive      bool       `json:"alive"`
+	Location   Location   `json:"location"`
+	Homebase   Location   `json:"homebase"`
+	SpawnTurn  Turn       `json:"spawn_turn"`
+	LookTurn   Turn       `json:"look_turn"`
+	DeadAtTurn Turn       `json:"dead_at_turn,omitempty"`
+	Cause      DeathCause `json:"cause,omitempty"`
+	Energy     float64    `json:"energy,omitempty"` // only with food, see Settings.FoodPatches
+	Genome     Genome     `json:"genome"`
+}
+
+// MetInfo is a peep met by another one
+type MetInfo struct {
+	ID   string `json:"id"`
+	Turn Turn   `json:"turn"` // last turn they met
+}
+
+// PeepDetail is everything about a peep, returned by /api/peeps/{id}
+type PeepDetail struct {
+	PeepInfo
+	Generation int        `json:"generation"`
+	Parents    []string   `json:"parents"`
+	Children   []string   `json:"children"`
+	Met        []MetInfo  `json:"met"`
+	Neighbors  []PeepInfo `json:"neighbors"` // alive peeps within view distance right now
+}
+
+// PeepPage is one page of peeps, returned by /api/peeps
+type PeepPage struct {
+	Total  int        `json:"total"` // number of peeps matching the filter
+	Offset int        `json:"offset"`
+	Limit  int        `json:"limit"`
+	Peeps  []PeepInfo `json:"peeps"`
+}
+
+// peepFilter selects peeps, zero values match everything
+type peepFilter struct {
+	gender PeepGender
+	alive  *bool
+	minAge *PeepAge
+	maxAge *PeepAge
+}
+
+// matches returns true if the peep passes the filter
+func (f peepFilter) matches(p PeepInfo) bool {
+	if f.gender != "" && p.Gender != f.gender {
+		return false
+	}
+	if f.alive != nil && p.Alive != *f.alive {
+		return false
+	}
+	if f.minAge != nil && p.Age < *f.minAge {
+		return false
+	}
+	if f.maxAge != nil && p.Age > *f.maxAge {
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as the response
+func writeJSON(writer http.ResponseWriter, v interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(writer).Encode(v); err != nil {
+		Log("Error writing response: ", err)
+	}
+}
+
+// writeError writes err as a JSON response with the given status
+func writeError(writer http.ResponseWriter, status int, err error) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(map[string]string{"error": err.Error()})
+}
+
+// queryInt parses an optional integer query parameter
+func queryInt(r *http.Request, name string) (*int64, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v: %v", name, v)
+	}
+	return &i, nil
+}
+
+// queryAge parses an optional age query parameter
+func queryAge(r *http.Request, name string) (*PeepAge, error) {
+	i, err := queryInt(r, name)
+	if i == nil || err != nil {
+		return nil, err
+	}
+	age := PeepAge(*i)
+	return &age, nil
+}
+
+// peepInfo returns the public information about a peep
+func (w *World) peepInfo(e Exister) PeepInfo {
+	info := PeepInfo{
+		ID:       e.ID(),
+		Alive:    isPresent(e),
+		Location: e.Location(),
+	}
+	if s, ok := e.(Social); ok {
+		info.Gender = s.Gender()
+		info.Homebase = s.Homebase()
+	}
+	if r, ok := e.(Reproducer); ok {
+		info.SpawnTurn = r.SpawnTurn()
+	}
+	if p, ok := e.(Perceiver); ok {
+		info.LookTurn = p.LookTurn()
+	}
+	if l, ok := e.(Living); ok {
+		info.Age = l.Age()
+		info.Energy = l.Energy()
+		info.Genome = l.Genome()
+		if !l.IsAlive() {
+			info.DeadAtTurn = l.DeadAtTurn()
+			info.Cause = l.CauseOfDeath()
+		}
+	}
+	return info
+}
+
+// WorldInfo returns the current state of the world
+func (w *World) WorldInfo() WorldInfo {
+	info := WorldInfo{
+		Name:     w.name,
+		Turn:     w.turn,
+		Alive:    w.AlivePeepCount(),
+		MaxPeeps: w.settings.MaxPeeps,
+		Ages: AgeInfo{
+			Max: w.PeepMaxAge(),
+			Avg: w.PeepAvgAge(),
+			Min: w.PeepMinAge(),
+		},
+		Genome:    w.GenomeStats(),
+		Genders:   w.PeepGenders(),
+		Predators: w.AlivePredatorCount(),
+		Homebases: make(map[PeepGender]Location),
+	}
+	for gender, loc := range w.homebase {
+		info.Homebases[gender] = loc
+	}
+	return info
+}
+
+// WorldHandler serves the state of the world
+func (w *World) WorldHandler(writer http.ResponseWriter, r *http.Request) {
+	writeJSON(writer, w.View().Info)
+}
+
+// SettingsHandler serves the world settings
+func (w *World) SettingsHandler(writer http.ResponseWriter, r *http.Request) {
+	s := w.View().Settings
+	s.TurnTime = w.ControlInfo().TurnTime // changes at any time, see SetTurnTime
+	writeJSON(writer, s)
+}
+
+// PeepsHandler serves a page of peeps, ordered by location.
+// Query parameters (all optional): gender, alive (true/false), min_age, max_age, offset, limit
+func (w *World) PeepsHandler(writer http.ResponseWriter, r *http.Request) {
+	var (
+		f   peepFilter
+		err error
+	)
+	query := r.URL.Query()
+
+	f.gender = PeepGender(query.Get("gender"))
+	if v := query.Get("alive"); v != "" {
+		alive, err := strconv.ParseBool(v)
+		if err != nil {
+			writeError(writer, http.StatusBadRequest, fmt.Errorf("invalid alive: %v", v))
+			return
+		}
+		f.alive = &alive
+	}
+	if f.minAge, err = queryAge(r, "min_age"); err != nil {
+		writeError(writer, http.StatusBadRequest, err)
+		return
+	}
+	if f.maxAge, err = queryAge(r, "max_age"); err != nil {
+		writeError(writer, http.StatusBadRequest, err)
+		return
+	}
+
+	page := PeepPage{Limit: defaultPageSize, Peeps: []PeepInfo{}}
+	offset, err := queryInt(r, "offset")
+	if err != nil || (offset != nil && *offset < 0) {
+		writeError(writer, http.StatusBadRequest, fmt.Errorf("invalid offset: %v", query.Get("offset")))
+		return
+	}
+	if offset != nil {
+		page.Offset = int(*offset)
+	}
+	limit, err := queryInt(r, "limit")
+	if err != nil || (limit != nil && (*limit < 1 || *limit > maxPageSize)) {
+		writeError(writer, http.StatusBadRequest, fmt.Errorf("invalid limit, must be in [1, %v]: %v", maxPageSize, query.Get("limit")))
+		return
+	}
+	if limit != nil {
+		page.Limit = int(*limit)
+	}
+
+	for _, p := range w.View().Peeps {
+		if !f.matches(p.PeepInfo) {
+			continue
+		}
+		if page.Total >= page.Offset && len(page.Peeps) < page.Limit {
+			page.Peeps = append(page.Peeps, p.PeepInfo)
+		}
+		page.Total++
+	}
+	writeJSON(writer, page)
+}
+
+// PeepHandler serves everything about a single peep
+func (w *World) PeepHandler(writer http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	detail, ok := w.View().Peep(id)
+	if !ok {
+		writeError(writer, http.StatusNotFound, fmt.Errorf("no such peep: %v", id))
+		return
+	}
+	writeJSON(writer, detail)
+}
@@ -0,0 +1,126 @@
+package world
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// get serves a GET request for path and decodes the JSON response into v
+func get(w *World, path string, v interface{}) int {
+	recorder := httptest.NewRecorder()
+	w.Router().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	if v != nil {
+		json.NewDecoder(recorder.Body).Decode(v)
+	}
+	return recorder.Code
+}
+
+func TestWorldAPI(t *testing.T) {
+	w := genWorld()
+	w.SetHomebase("red", Location{9, 9, 0})
+	p1, _ := w.NewPeep("red", Location{1, 1, 0})
+	p2, _ := w.NewPeep("blue", Location{2, 1, 0})
+	p1.age, p2.age = 3, 7
+	w.Publish()
+
+	Convey("/api/world returns counts and ages.", t, func() {
+		var info WorldInfo
+		So(get(w, "/api/world", &info), ShouldEqual, http.StatusOK)
+		So(info.Name, ShouldEqual, "Alpha1")
+		So(info.Alive, ShouldEqual, 2)
+		So(info.Ages, ShouldResemble, AgeInfo{Max: 7, Avg: 5, Min: 3})
+		So(info.Genders, ShouldResemble, map[PeepGender]int64{"red": 1, "blue": 1})
+		So(info.Homebases["red"], ShouldResemble, Location{9, 9, 0})
+	})
+
+	Convey("/api/settings returns the settings.", t, func() {
+		var s Settings
+		So(get(w, "/api/settings", &s), ShouldEqual, http.StatusOK)
+		So(s, ShouldResemble, w.settings)
+	})
+}
+
+func TestPeepsAPI(t *testing.T) {
+	w := genWorld()
+	for x := int32(1); x <= 6; x++ {
+		gender := PeepGender("red")
+		if x%2 == 0 {
+			gender = "blue"
+		}
+		p, _ := w.NewPeep(gender, Location{x, 1, 0})
+		p.age = PeepAge(x)
+	}
+	w.LocationExister(Location{1, 1, 0}).(*Peep).Die(w.turn)
+	w.Publish()
+
+	Convey("All peeps are listed by default.", t, func() {
+		var page PeepPage
+		So(get(w, "/api/peeps", &page), ShouldEqual, http.StatusOK)
+		So(page.Total, ShouldEqual, 6)
+		So(len(page.Peeps), ShouldEqual, 6)
+	})
+
+	Convey("Peeps are filtered.", t, func() {
+		var page PeepPage
+		get(w, "/api/peeps?gender=red&alive=true", &page)
+		So(page.Total, ShouldEqual, 2)
+
+		get(w, "/api/peeps?min_age=2&max_age=4", &page)
+		So(page.Total, ShouldEqual, 3)
+		So(page.Peeps[0].Location, ShouldResemble, Location{2, 1, 0})
+	})
+
+	Convey("Peeps are paginated.", t, func() {
+		var page PeepPage
+		get(w, "/api/peeps?offset=4&limit=3", &page)
+		So(page.Total, ShouldEqual, 6)
+		So(len(page.Peeps), ShouldEqual, 2)
+		So(page.Peeps[0].Age, ShouldEqual, 5)
+	})
+
+	Convey("Bad queries are rejected.", t, func() {
+		So(get(w, "/api/peeps?alive=maybe", nil), ShouldEqual, http.StatusBadRequest)
+		So(get(w, "/api/peeps?min_age=old", nil), ShouldEqual, http.StatusBadRequest)
+		So(get(w, "/api/peeps?limit=0", nil), ShouldEqual, http.StatusBadRequest)
+		So(get(w, "/api/peeps?offset=-1", nil), ShouldEqual, http.StatusBadRequest)
+	})
+}
+
+func TestPeepAPI(t *testing.T) {
+	w := genWorld()
+	p1, _ := w.NewPeep("red", Location{1, 1, 0})
+	p2, _ := w.NewPeep("blue", Location{2, 2, 0})
+	w.NewPeep("blue", Location{8, 8, 0}) // too far to be seen
+	p1.Meet(p2, 3)
+	w.Publish()
+
+	Convey("A peep is returned with its meetings and neighbors.", t, func() {
+		var detail PeepDetail
+		So(get(w, "/api/peeps/"+p1.ID(), &detail), ShouldEqual, http.StatusOK)
+		So(detail.ID, ShouldEqual, p1.ID())
+		So(detail.Location, ShouldResemble, Location{1, 1, 0})
+		So(detail.Met, ShouldResemble, []MetInfo{{ID: p2.ID(), Turn: 3}})
+		So(len(detail.Neighbors), ShouldEqual, 1)
+		So(detail.Neighbors[0].ID, ShouldEqual, p2.ID())
+	})
+
+	Convey("Neighbors are those within the peep's own view distance.", t, func() {
+		p1.genome.ViewDistance = 7
+		w.Publish()
+		detail, _ := w.View().Peep(p1.ID())
+		So(len(detail.Neighbors), ShouldEqual, 2)
+
+		p1.genome.ViewDistance = 0
+		w.Publish()
+		detail, _ = w.View().Peep(p1.ID())
+		So(detail.Neighbors, ShouldBeEmpty)
+	})
+
+	Convey("Unknown peeps are not found.", t, func() {
+		So(get(w, "/api/peeps/nobody", nil), ShouldEqual, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,71 @@
+// Command replay rebuilds a world at any turn from its event log (see world -event-log),
+// without re-running the simulation.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/DanTulovsky/world"
+)
+
+var (
+	eventLog = flag.String("event-log", "", "event log written by world -event-log")
+	turn     = flag.Int64("turn", 0, "turn to rebuild the world at, 0 is before the first turn")
+	asJSON   = flag.Bool("json", false, "print the state as JSON, including dead peeps")
+)
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		world.Log(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	if *eventLog == "" {
+		return fmt.Errorf("-event-log is required")
+	}
+	f, err := os.Open(*eventLog)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s, err := world.Replay(bufio.NewReader(f), world.Turn(*turn))
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	}
+
+	alive := s.Alive()
+	genders := make(map[world.PeepGender]int)
+	for _, p := range alive {
+		genders[p.Gender]++
+	}
+
+	fmt.Printf("Turn: %v\n", s.Turn)
+	fmt.Printf("Peeps Alive/Dead: %v/%v\n", len(alive), len(s.Peeps)-len(alive))
+	fmt.Printf("Genders: %v\n", genders)
+	for _, p := range alive {
+		fmt.Printf("%v age:%v gender:%v location:%v\n", p.ID, p.Age, p.Gender, p.Location)
+	}
+	if len(s.Predators) > 0 {
+		predators := s.AlivePredators()
+		fmt.Printf("Predators Alive/Dead: %v/%v\n", len(predators), len(s.Predators)-len(predators))
+		for _, p := range predators {
+			fmt.Printf("%v predator age:%v kills:%v location:%v\n", p.ID, p.Age, p.Kills, p.Location)
+		}
+	}
+	return nil
+}
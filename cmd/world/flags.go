@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strconv"
+)
+
+// int32Value is a flag.Value for int32 fields
+type int32Value struct {
+	v *int32
+}
+
+func (i int32Value) String() string {
+	if i.v == nil {
+		return "0"
+	}
+	return strconv.FormatInt(int64(*i.v), 10)
+}
+
+func (i int32Value) Set(s string) error {
+	v, err := strconv.ParseInt(s, 0, 32)
+	if err != nil {
+		return err
+	}
+	*i.v = int32(v)
+	return nil
+}
@@ -0,0 +1,281 @@
+// Command world runs a world simulation, either in the terminal or headless.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/DanTulovsky/world"
+	termbox "github.com/nsf/termbox-go"
+)
+
+var (
+	name      = flag.String("name", "Alpha1", "name of the world")
+	debug     = flag.Bool("debug", false, "start paused, advance one turn at a time (press Enter) and print the world after each turn")
+	headless  = flag.Bool("headless", false, "run without drawing in the terminal")
+	turns     = flag.Int64("turns", 0, "number of turns to run, 0 runs until interrupted")
+	webAddr   = flag.String("web-addr", ":6001", "address to serve world information on, empty disables the web server")
+	scenario  = flag.String("scenario", "", "scenario file (.json, .yaml) to create the world from, settings flags are ignored")
+	terrain   = flag.String("terrain", "", "text map of walls (#), water (~) and rough ground (,) starting at the top left corner, ignored with -scenario and -load")
+	load      = flag.String("load", "", "snapshot file to resume the world from, settings flags are ignored")
+	save      = flag.String("save", "", "snapshot file to save the world to on exit")
+	eventLog  = flag.String("event-log", "", "file to write all events to, one JSON event per line")
+	family    = flag.String("family-tree", "", "file to write the family tree to on exit, .json or .dot (Graphviz)")
+	statsFile = flag.String("stats-file", "", "file to write population stats to every turn, .csv or .bin (compact binary)")
+	logStats  = flag.Duration("log-metrics", 0, "log all metrics to stderr at this interval, 0 disables; metrics are always served on /metrics")
+)
+
+// settingsFlags registers one flag per Settings field and returns the settings they fill in
+func settingsFlags() *world.Settings {
+	// defaults for flags registered with flag.Var
+	s := &world.Settings{
+		Size:             &world.Size{MaxX: 40, MaxY: 15, MinX: -40, MinY: -15},
+		PeepViewDistance: 3,
+
+		PredatorViewDistance: 4,
+	}
+
+	flag.Int64Var((*int64)(&s.MaxAge), "max-age", 80, "peeps cannot live beyond this age")
+	flag.Int64Var(&s.MaxPeeps, "max-peeps", 500, "absolute max peeps")
+	flag.Float64Var(&s.NewPeep, "new-peep", 0.5, "chances a new peep is born [0-1]")
+	flag.Float64Var(&s.NewPeepModifier, "new-peep-modifier", 100, "the lower this number, the less chance a new peep shows up as the population grows")
+	flag.Int64Var(&s.NewPeepMax, "new-peep-max", 20, "when this many peeps exist, no new peeps are spawned from origin")
+	flag.Float64Var(&s.RandomDeath, "random-death", 0.0001, "chances of a random death")
+	flag.Var(int32Value{&s.Size.MaxX}, "max-x", "max X of the world, one line is used as the border")
+	flag.Var(int32Value{&s.Size.MaxY}, "max-y", "max Y of the world, one line is used as the border")
+	flag.Var(int32Value{&s.Size.MaxZ}, "max-z", "max Z of the world")
+	flag.Var(int32Value{&s.Size.MinX}, "min-x", "min X of the world, one line is used as the border")
+	flag.Var(int32Value{&s.Size.MinY}, "min-y", "min Y of the world, one line is used as the border")
+	flag.Var(int32Value{&s.Size.MinZ}, "min-z", "min Z of the world")
+	flag.Int64Var((*int64)(&s.SpawnAge), "spawn-age", 20, "minimum age to spawn")
+	flag.Float64Var(&s.SpawnProbability, "spawn-probability", 0.5, "chances of two peeps that meet spawning a new one")
+	flag.DurationVar(&s.TurnTime, "turn-time", 100*time.Millisecond, "how long each turn takes")
+	flag.Int64Var((*int64)(&s.YoungHightlightAge), "young-highlight-age", 5, "up to this age, peeps are highlighted")
+	flag.Int64Var((*int64)(&s.PeepRememberTurns), "peep-remember-turns", 3, "how many turns peeps remember their surroundings for")
+	flag.Var(int32Value{&s.PeepViewDistance}, "peep-view-distance", "how far peeps can see")
+	flag.Int64Var((*int64)(&s.PeepSpawnInterval), "peep-spawn-interval", 10, "how many turns to wait after a spawn before spawning again")
+	flag.BoolVar(&s.KillIfSurroundByOther, "kill-if-surrounded-by-other", false, "peeps completely surrounded by other genders die")
+	flag.BoolVar(&s.KillIfSurroundedBySame, "kill-if-surrounded-by-same", false, "peeps completely surrounded by the same gender die")
+	flag.BoolVar(&s.KillIfSurrounded, "kill-if-surrounded", true, "peeps completely surrounded die")
+	flag.IntVar(&s.MaxGenders, "max-genders", 4, "max different genders, 1-4")
+	flag.Int64Var(&s.Seed, "seed", 0, "seed for all randomness, 0 picks one from the clock")
+	flag.StringVar((*string)(&s.MovementPolicy), "movement-policy", string(world.MovementRandom), "how peeps move with no mate in sight: random, or lifecycle (young leave home, adults return, elders wander)")
+	flag.Int64Var((*int64)(&s.ElderAge), "elder-age", 0, "from this age peeps wander with the lifecycle movement policy, 0 means three quarters of their lifespan")
+	flag.StringVar((*string)(&s.Topology), "topology", string(world.TopologyBounded), "how the edges of the world connect: bounded, torus (all edges wrap around) or cylinder (left and right wrap around)")
+	flag.IntVar(&s.FoodPatches, "food-patches", 0, "number of food patches, 0 means peeps don't need food")
+	flag.Float64Var(&s.FoodMax, "food-max", 10, "most food a patch holds")
+	flag.Float64Var(&s.FoodRegrowth, "food-regrowth", 0.5, "food a patch regrows every turn")
+	flag.Float64Var(&s.MaxEnergy, "max-energy", 20, "energy of a newborn peep, and the most a peep can have")
+	flag.Float64Var(&s.MoveEnergy, "move-energy", 0.2, "energy a peep spends per step")
+	flag.Float64Var(&s.TurnEnergy, "turn-energy", 0.2, "energy a peep spends every turn")
+	flag.Float64Var(&s.EatAmount, "eat-amount", 5, "most energy a peep gets from eating once")
+	flag.Float64Var(&s.MutationRate, "mutation-rate", 0, "chances of each gene of a newborn peep mutating [0-1]")
+	flag.IntVar(&s.Predators, "predators", 0, "number of predators hunting peeps, 0 means none")
+	flag.Int64Var(&s.MaxPredators, "max-predators", 0, "predators stop spawning at this many, 0 means no limit")
+	flag.Int64Var((*int64)(&s.PredatorMaxAge), "predator-max-age", 100, "predators cannot live beyond this age")
+	flag.Var(int32Value{&s.PredatorViewDistance}, "predator-view-distance", "how far predators can see")
+	flag.Float64Var(&s.PredatorEnergy, "predator-energy", 30, "most energy a predator has; born with half, spawns when full")
+	flag.Float64Var(&s.PredatorHunger, "predator-hunger", 0.5, "energy a predator spends every turn")
+	flag.Float64Var(&s.PredatorFeed, "predator-feed", 10, "energy a predator gets from every peep it eats")
+
+	return s
+}
+
+func main() {
+	settings := settingsFlags()
+	flag.Parse()
+
+	if err := run(settings); err != nil {
+		world.Log(err)
+		os.Exit(1)
+	}
+}
+
+// run creates the world and runs it until done
+func run(settings *world.Settings) error {
+	w, err := newWorld(settings)
+	if err != nil {
+		return err
+	}
+
+	if *eventLog != "" {
+		f, err := os.Create(*eventLog)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		log := bufio.NewWriter(f)
+		defer log.Flush()
+		sink := world.NewJSONEventLog(log)
+		// scenarios, snapshots and predators fill the world before the log exists
+		if err := w.RecordPopulation(sink); err != nil {
+			return err
+		}
+		w.AddEventSink(sink)
+	}
+
+	if *statsFile != "" {
+		format := filepath.Ext(*statsFile)
+		if format != ".csv" && format != ".bin" {
+			return fmt.Errorf("unknown stats file format %q, use .csv or .bin", format)
+		}
+		f, err := os.Create(*statsFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		out := bufio.NewWriter(f)
+		defer out.Flush()
+		if format == ".csv" {
+			w.AddTurnRecorder(world.NewCSVTurnRecorder(out, w.Genders()))
+		} else {
+			w.AddTurnRecorder(world.NewBinaryTurnRecorder(out, w.Genders()))
+		}
+	}
+
+	if *logStats > 0 {
+		w.LogMetrics(*logStats, os.Stderr)
+	}
+
+	w.Run(*webAddr)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		w.Shutdown(ctx)
+	}()
+
+	if *debug {
+		// advance with Enter, or over http
+		w.Pause()
+	}
+
+	if *headless {
+		err = runHeadless(w)
+	} else {
+		err = runTermbox(w)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.Show(os.Stderr)
+
+	if *family != "" {
+		if err := saveFamilyTree(w, *family); err != nil {
+			return err
+		}
+	}
+	if *save != "" {
+		return saveWorld(w, *save)
+	}
+	return nil
+}
+
+// saveFamilyTree writes the family tree of the world to path, in the format given by its extension
+func saveFamilyTree(w *world.World, path string) error {
+	write := w.WriteFamilyTreeJSON
+	if filepath.Ext(path) == ".dot" {
+		write = w.WriteFamilyTreeDOT
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// saveWorld writes a snapshot of the world to path
+func saveWorld(w *world.World, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := w.Snapshot(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// newWorld resumes the world from a snapshot, or creates it from the scenario file or the settings flags
+func newWorld(settings *world.Settings) (*world.World, error) {
+	if *load != "" {
+		f, err := os.Open(*load)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return world.LoadWorld(f, *debug)
+	}
+
+	if *scenario != "" {
+		sc, err := world.LoadScenarioFile(*scenario)
+		if err != nil {
+			return nil, err
+		}
+		if sc.Name == "" {
+			sc.Name = *name
+		}
+		return sc.NewWorld(*debug)
+	}
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	w := world.NewWorld(*name, *settings, *debug)
+	w.SetDefaultHomebases()
+	if *terrain != "" {
+		if err := w.LoadTerrainFile(*terrain); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.PlacePredators(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// runHeadless runs the world until done or interrupted
+func runHeadless(w *world.World) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		w.Stop()
+	}()
+
+	return w.Loop(world.Turn(*turns))
+}
+
+// runTermbox runs the world in the terminal until done or the user exits
+func runTermbox(w *world.World) error {
+	if err := termbox.Init(); err != nil {
+		return err
+	}
+	defer termbox.Close()
+
+	w.SetRenderer(world.NewTermboxRenderer())
+
+	// Listen for input events on keyboard
+	controller := world.NewTermboxController(w)
+	go func() {
+		for {
+			controller.Handle(termbox.PollEvent())
+		}
+	}()
+
+	return w.Loop(world.Turn(*turns))
+}
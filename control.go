@@ -0,0 +1,280 @@
+package world
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RunState describes whether the world is advancing, see Loop
+type RunState string
+
+const (
+	StateRunning  RunState = "running"  // a turn every TurnTime
+	StatePaused   RunState = "paused"   // waiting for Resume or Step
+	StateStepping RunState = "stepping" // running a number of turns, then pausing
+	StateStopped  RunState = "stopped"  // done for good, Loop returns
+)
+
+// ErrStopped is returned when changing the run state of a stopped world
+var ErrStopped = errors.New("world is stopped")
+
+// runControl holds the run state of a world.
+// It is changed from other goroutines (user input, http) while Loop runs.
+type runControl struct {
+	lock     sync.Mutex
+	state    RunState
+	steps    int           // turns left while stepping
+	turnTime time.Duration // time between turns
+	changed  chan struct{} // closed and replaced whenever anything changes
+}
+
+func newRunControl(turnTime time.Duration) *runControl {
+	return &runControl{
+		state:    StateRunning,
+		turnTime: turnTime,
+		changed:  make(chan struct{}),
+	}
+}
+
+// notify wakes up Loop, the lock must be held
+func (c *runControl) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// ControlInfo describes the run state of the world
+type ControlInfo struct {
+	State    RunState      `json:"state"`
+	Steps    int           `json:"steps"` // turns left while stepping
+	TurnTime time.Duration `json:"turn_time"`
+}
+
+// MarshalJSON encodes TurnTime as a duration string, e.g. "100ms"
+func (c ControlInfo) MarshalJSON() ([]byte, error) {
+	type controlJSON ControlInfo
+	return json.Marshal(struct {
+		controlJSON
+		TurnTime string `json:"turn_time"`
+	}{controlJSON(c), c.TurnTime.String()})
+}
+
+// ControlInfo returns the run state of the world
+func (w *World) ControlInfo() ControlInfo {
+	w.control.lock.Lock()
+	defer w.control.lock.Unlock()
+
+	return ControlInfo{State: w.control.state, Steps: w.control.steps, TurnTime: w.control.turnTime}
+}
+
+// State returns the run state of the world
+func (w *World) State() RunState {
+	return w.ControlInfo().State
+}
+
+// setState changes the run state, unless the world is stopped
+func (w *World) setState(state RunState, steps int) error {
+	w.control.lock.Lock()
+	defer w.control.lock.Unlock()
+
+	return w.changeState(state, steps)
+}
+
+// changeState changes the run state, unless the world is stopped.
+// The control lock must be held.
+func (w *World) changeState(state RunState, steps int) error {
+	if w.control.state == StateStopped {
+		return ErrStopped
+	}
+	w.control.state = state
+	w.control.steps = steps
+	w.control.notify()
+	return nil
+}
+
+// Pause pauses the world.
+func (w *World) Pause() error {
+	return w.setState(StatePaused, 0)
+}
+
+// Resume runs the world again after Pause or Step.
+func (w *World) Resume() error {
+	return w.setState(StateRunning, 0)
+}
+
+// Step runs n more turns and pauses.
+func (w *World) Step(n int) error {
+	if n < 1 {
+		return errors.New("must step at least one turn")
+	}
+
+	w.control.lock.Lock()
+	defer w.control.lock.Unlock()
+
+	steps := n
+	if w.control.state == StateStepping {
+		steps += w.control.steps
+	}
+	return w.changeState(StateStepping, steps)
+}
+
+// Stop stops the world for good, Loop returns after the current turn.
+func (w *World) Stop() {
+	w.control.lock.Lock()
+	defer w.control.lock.Unlock()
+
+	w.control.state = StateStopped
+	w.control.steps = 0
+	w.control.notify()
+}
+
+// SetTurnTime changes how long each turn takes.
+func (w *World) SetTurnTime(d time.Duration) error {
+	if d < 0 {
+		return errors.New("turn time must not be negative")
+	}
+
+	w.control.lock.Lock()
+	defer w.control.lock.Unlock()
+
+	w.control.turnTime = d
+	w.control.notify()
+	return nil
+}
+
+// waitToAdvance blocks while the world is paused.
+// It returns false once the world is stopped.
+func (w *World) waitToAdvance() bool {
+	for {
+		w.control.lock.Lock()
+		state, changed := w.control.state, w.control.changed
+		w.control.lock.Unlock()
+
+		switch state {
+		case StateRunning, StateStepping:
+			return true
+		case StateStopped:
+			return false
+		}
+		<-changed
+	}
+}
+
+// turnDone counts down the turns left while stepping
+func (w *World) turnDone() {
+	w.control.lock.Lock()
+	defer w.control.lock.Unlock()
+
+	if w.control.state != StateStepping {
+		return
+	}
+	w.control.steps--
+	if w.control.steps <= 0 {
+		w.control.state = StatePaused
+		w.control.steps = 0
+		w.control.notify()
+	}
+}
+
+// waitTurnTime sleeps for the turn time, or less if anything changes in the meantime
+func (w *World) waitTurnTime() {
+	w.control.lock.Lock()
+	turnTime, changed := w.control.turnTime, w.control.changed
+	w.control.lock.Unlock()
+
+	if turnTime <= 0 {
+		// flat out, but let readers and browsers keep up
+		runtime.Gosched()
+		return
+	}
+
+	timer := time.NewTimer(turnTime)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-changed:
+	}
+}
+
+// Loop advances the world one turn every TurnTime, following its run state (see Pause, Resume, Step).
+// It returns once the world is stopped, or after the given number of turns (0 runs until stopped).
+func (w *World) Loop(turns Turn) error {
+	start := w.turn
+	for w.waitToAdvance() {
+		if err := w.NextTurn(); err != nil {
+			w.Stop()
+			return err
+		}
+		w.turnDone()
+
+		if turns > 0 && w.turn-start >= turns {
+			w.Stop()
+			break
+		}
+		w.waitTurnTime()
+	}
+	return nil
+}
+
+// writeControl writes the run state after a change, or the error that prevented it
+func (w *World) writeControl(writer http.ResponseWriter, err error) {
+	switch {
+	case err == ErrStopped:
+		writeError(writer, http.StatusConflict, err)
+	case err != nil:
+		writeError(writer, http.StatusBadRequest, err)
+	default:
+		writeJSON(writer, w.ControlInfo())
+	}
+}
+
+// ControlHandler serves the run state of the world
+func (w *World) ControlHandler(writer http.ResponseWriter, r *http.Request) {
+	writeJSON(writer, w.ControlInfo())
+}
+
+// PauseHandler pauses the world
+func (w *World) PauseHandler(writer http.ResponseWriter, r *http.Request) {
+	w.writeControl(writer, w.Pause())
+}
+
+// ResumeHandler resumes the world
+func (w *World) ResumeHandler(writer http.ResponseWriter, r *http.Request) {
+	w.writeControl(writer, w.Resume())
+}
+
+// StepHandler runs n turns (query parameter, default 1) and pauses
+func (w *World) StepHandler(writer http.ResponseWriter, r *http.Request) {
+	n := 1
+	if v := r.URL.Query().Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil {
+			w.writeControl(writer, fmt.Errorf("invalid n: %v", v))
+			return
+		}
+	}
+	w.writeControl(writer, w.Step(n))
+}
+
+// SpeedHandler changes the time between turns, e.g. ?turn_time=100ms
+func (w *World) SpeedHandler(writer http.ResponseWriter, r *http.Request) {
+	v := r.URL.Query().Get("turn_time")
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		w.writeControl(writer, fmt.Errorf("invalid turn_time: %q", v))
+		return
+	}
+	w.writeControl(writer, w.SetTurnTime(d))
+}
+
+// StopHandler stops the world for good
+func (w *World) StopHandler(writer http.ResponseWriter, r *http.Request) {
+	w.Stop()
+	w.writeControl(writer, nil)
+}
@@ -0,0 +1,143 @@
+package world
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// post serves a POST request for path and decodes the JSON response into v
+func post(w *World, path string, v *map[string]interface{}) int {
+	recorder := httptest.NewRecorder()
+	w.Router().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, nil))
+	if v != nil {
+		*v = make(map[string]interface{})
+		json.NewDecoder(recorder.Body).Decode(v)
+	}
+	return recorder.Code
+}
+
+// waitForState waits until the world is in the given state, or a second has passed
+func waitForState(w *World, state RunState) RunState {
+	deadline := time.Now().Add(time.Second)
+	for w.State() != state && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	return w.State()
+}
+
+func TestRunState(t *testing.T) {
+	w := genWorld()
+
+	Convey("Worlds start running.", t, func() {
+		So(w.State(), ShouldEqual, StateRunning)
+	})
+
+	Convey("Pause, Step and Resume change the state.", t, func() {
+		So(w.Pause(), ShouldBeNil)
+		So(w.State(), ShouldEqual, StatePaused)
+
+		So(w.Step(2), ShouldBeNil)
+		So(w.Step(3), ShouldBeNil)
+		So(w.ControlInfo(), ShouldResemble, ControlInfo{State: StateStepping, Steps: 5})
+		So(w.Step(0), ShouldNotBeNil)
+
+		So(w.Resume(), ShouldBeNil)
+		So(w.State(), ShouldEqual, StateRunning)
+	})
+
+	Convey("Steps asked for at the same time all add up.", t, func() {
+		So(w.Pause(), ShouldBeNil)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.Step(1)
+			}()
+		}
+		wg.Wait()
+		So(w.ControlInfo(), ShouldResemble, ControlInfo{State: StateStepping, Steps: 50})
+		So(w.Resume(), ShouldBeNil)
+	})
+
+	Convey("Turn time can be changed.", t, func() {
+		So(w.SetTurnTime(time.Second), ShouldBeNil)
+		So(w.ControlInfo().TurnTime, ShouldEqual, time.Second)
+		So(w.Settings().TurnTime, ShouldEqual, time.Second)
+		So(w.SetTurnTime(-time.Second), ShouldNotBeNil)
+	})
+
+	Convey("Stopped worlds stay stopped.", t, func() {
+		w.Stop()
+		So(w.Resume(), ShouldEqual, ErrStopped)
+		So(w.Step(1), ShouldEqual, ErrStopped)
+		So(w.State(), ShouldEqual, StateStopped)
+	})
+}
+
+func TestLoop(t *testing.T) {
+	Convey("Loop runs the requested number of turns.", t, func() {
+		w := genWorld()
+		So(w.Loop(5), ShouldBeNil)
+		So(w.Turn(), ShouldEqual, 5)
+		So(w.State(), ShouldEqual, StateStopped)
+	})
+
+	Convey("Loop waits while paused and runs steps.", t, func() {
+		w := genWorld()
+		w.Pause()
+
+		done := make(chan error)
+		go func() { done <- w.Loop(0) }()
+
+		So(w.Step(3), ShouldBeNil)
+		So(waitForState(w, StatePaused), ShouldEqual, StatePaused)
+
+		w.Stop()
+		So(<-done, ShouldBeNil)
+		So(w.Turn(), ShouldEqual, 3)
+	})
+}
+
+func TestControlAPI(t *testing.T) {
+	w := genWorld()
+	var info map[string]interface{}
+
+	Convey("The run state can be changed over http.", t, func() {
+		So(post(w, "/api/control/pause", &info), ShouldEqual, http.StatusOK)
+		So(info["state"], ShouldEqual, "paused")
+
+		So(post(w, "/api/control/step?n=4", &info), ShouldEqual, http.StatusOK)
+		So(info["state"], ShouldEqual, "stepping")
+		So(info["steps"], ShouldEqual, 4)
+
+		So(post(w, "/api/control/speed?turn_time=250ms", &info), ShouldEqual, http.StatusOK)
+		So(info["turn_time"], ShouldEqual, "250ms")
+
+		So(post(w, "/api/control/resume", &info), ShouldEqual, http.StatusOK)
+		So(info["state"], ShouldEqual, "running")
+	})
+
+	Convey("Bad requests are rejected.", t, func() {
+		So(post(w, "/api/control/step?n=zero", nil), ShouldEqual, http.StatusBadRequest)
+		So(post(w, "/api/control/speed?turn_time=fast", nil), ShouldEqual, http.StatusBadRequest)
+		So(get(w, "/api/control/pause", nil), ShouldEqual, http.StatusMethodNotAllowed)
+	})
+
+	Convey("Stopped worlds cannot be resumed.", t, func() {
+		So(post(w, "/api/control/stop", &info), ShouldEqual, http.StatusOK)
+		So(info["state"], ShouldEqual, "stopped")
+		So(post(w, "/api/control/resume", nil), ShouldEqual, http.StatusConflict)
+	})
+
+	Convey("The run state is served.", t, func() {
+		So(get(w, "/api/control", &info), ShouldEqual, http.StatusOK)
+		So(info["state"], ShouldEqual, "stopped")
+	})
+}
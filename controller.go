@@ -0,0 +1,47 @@
+package world
+
+import (
+	"os"
+
+	termbox "github.com/nsf/termbox-go"
+)
+
+// TermboxController turns keyboard input from termbox into actions on a world.
+//
+//	Esc    stops the world
+//	Space  prints world information
+//	Ctrl-S prints world settings
+//	Enter  advances one turn and pauses
+//	P      pauses or resumes the world
+type TermboxController struct {
+	world *World
+}
+
+// NewTermboxController returns a controller for the world
+func NewTermboxController(w *World) *TermboxController {
+	return &TermboxController{world: w}
+}
+
+// Handle acts on a single input event
+func (c *TermboxController) Handle(ev termbox.Event) {
+	if ev.Type != termbox.EventKey {
+		return
+	}
+
+	switch {
+	case ev.Key == termbox.KeyEsc:
+		c.world.Stop()
+	case ev.Key == termbox.KeySpace:
+		c.world.View().Show(os.Stderr)
+	case ev.Key == termbox.KeyCtrlS:
+		c.world.View().ShowSettings(os.Stderr)
+	case ev.Key == termbox.KeyEnter:
+		c.world.Step(1)
+	case ev.Ch == 'p' || ev.Ch == 'P':
+		if c.world.State() == StatePaused {
+			c.world.Resume()
+		} else {
+			c.world.Pause()
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package world
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// EventType is the kind of thing that happened
+type EventType string
+
+const (
+	EventBirth         EventType = "birth"          // a peep was born
+	EventDeath         EventType = "death"          // a peep died
+	EventMeet          EventType = "meet"           // two peeps bumped into each other
+	EventMove          EventType = "move"           // a peep or predator moved
+	EventSpawnBlocked  EventType = "spawn_blocked"  // a peep should have been born, but could not be
+	EventTurn          EventType = "turn"           // the turn ended, all alive peeps aged by one
+	EventKill          EventType = "kill"           // a predator ate a peep
+	EventPredatorBirth EventType = "predator_birth" // a predator was born
+	EventPredatorDeath EventType = "predator_death" // a predator died
+)
+
+// DeathCause is the reason a peep died
+type DeathCause string
+
+const (
+	DeathOldAge            DeathCause = "old_age"
+	DeathRandom            DeathCause = "random"
+	DeathSurroundedByOther DeathCause = "surrounded_by_other"
+	DeathSurroundedBySame  DeathCause = "surrounded_by_same"
+	DeathSurrounded        DeathCause = "surrounded"
+	DeathStarvation        DeathCause = "starvation" // ran out of energy, see Settings.FoodPatches
+	DeathEaten             DeathCause = "eaten"      // caught by a predator, see Predator
+	DeathUnknown           DeathCause = "unknown"    // killed from outside the world, see Peep.Die
+)
+
+// deathCauses lists all causes of death
+var deathCauses = []DeathCause{
+	DeathOldAge,
+	DeathRandom,
+	DeathSurroundedByOther,
+	DeathSurroundedBySame,
+	DeathSurrounded,
+	DeathStarvation,
+	DeathEaten,
+	DeathUnknown,
+}
+
+// Event describes something that happened in the world.
+// Only the fields relevant to the event type are set.
+type Event struct {
+	Type     EventType  `json:"type"`
+	Turn     Turn       `json:"turn"`
+	ID       string     `json:"id,omitempty"`       // the peep the event is about, or the predator
+	Other    string     `json:"other,omitempty"`    // the other peep in a meeting or spawn, the peep eaten in a kill
+	Gender   PeepGender `json:"gender,omitempty"`   // birth
+	Parents  []string   `json:"parents,omitempty"`  // birth, if the peep spawned from others; predator_birth
+	Age      PeepAge    `json:"age,omitempty"`      // birth and death
+	From     *Location  `json:"from,omitempty"`     // move
+	Location *Location  `json:"location,omitempty"` // birth, death, move (destination), kill and predators
+	Cause    DeathCause `json:"cause,omitempty"`    // death and predator_death
+	Reason   string     `json:"reason,omitempty"`   // spawn_blocked
+}
+
+// EventSink receives the events of a world as they happen
+type EventSink interface {
+	Record(e Event) error
+}
+
+// JSONEventLog writes events as JSON, one per line
+type JSONEventLog struct {
+	encoder *json.Encoder
+}
+
+// NewJSONEventLog returns an event log writing to writer
+func NewJSONEventLog(writer io.Writer) *JSONEventLog {
+	return &JSONEventLog{encoder: json.NewEncoder(writer)}
+}
+
+// Record writes the event on its own line
+func (l *JSONEventLog) Record(e Event) error {
+	return l.encoder.Encode(e)
+}
+
+// AddEventSink adds a sink that receives every event from now on
+func (w *World) AddEventSink(s EventSink) {
+	w.eventSinks = append(w.eventSinks, s)
+}
+
+// TurnEvents returns the events of the last turn
+func (w *World) TurnEvents() []Event {
+	return w.turnEvents
+}
+
+// emit records an event for the current turn and hands it to all sinks
+func (w *World) emit(e Event) {
+	e.Turn = w.turn
+	w.turnEvents = append(w.turnEvents, e)
+
+	for _, s := range w.eventSinks {
+		if err := s.Record(e); err != nil {
+			Log("Error recording event: ", err)
+		}
+	}
+}
+
+// RecordPopulation hands sink a birth event for every alive peep and predator of the current turn.
+// Use it when adding a sink to a world that is already populated, so the log starts with everyone in it.
+func (w *World) RecordPopulation(sink EventSink) error {
+	for _, e := range w.grid.Occupants() {
+		var event Event
+		switch p := e.(type) {
+		case *Peep:
+			event = birthEvent(p)
+		case *Predator:
+			event = predatorBirthEvent(p)
+		default:
+			continue
+		}
+		if !isAlive(e) {
+			continue
+		}
+		event.Turn = w.turn
+		if err := sink.Record(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// birthEvent returns the birth event of a peep
+func birthEvent(p *Peep) Event {
+	loc := p.Location()
+	return Event{Type: EventBirth, ID: p.ID(), Gender: p.Gender(), Age: p.Age(), Location: &loc, Parents: p.Parents()}
+}
+
+// predatorBirthEvent returns the birth event of a predator
+func predatorBirthEvent(p *Predator) Event {
+	loc := p.Location()
+	return Event{Type: EventPredatorBirth, ID: p.ID(), Age: p.Age(), Location: &loc}
+}
+
+// emitBirth records the birth of a peep
+func (w *World) emitBirth(p *Peep) {
+	w.emit(birthEvent(p))
+}
+
+// emitDeath records the death of a peep
+func (w *World) emitDeath(p *Peep) {
+	w.recordDeath(p)
+	loc := p.Location()
+	w.emit(Event{Type: EventDeath, ID: p.ID(), Age: p.Age(), Location: &loc, Cause: p.CauseOfDeath()})
+}
+
+// emitSpawnBlocked records a spawn that did not happen, left and right are the would-be parents if any
+func (w *World) emitSpawnBlocked(left, right Exister, err error) {
+	e := Event{Type: EventSpawnBlocked, Reason: err.Error()}
+	if left != nil {
+		e.ID = left.ID()
+	}
+	if right != nil {
+		e.Other = right.ID()
+	}
+	w.emit(e)
+}
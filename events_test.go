@@ -0,0 +1,122 @@
+package world
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// eventRecorder keeps every event it receives
+type eventRecorder struct {
+	events []Event
+}
+
+func (r *eventRecorder) Record(e Event) error {
+	r.events = append(r.events, e)
+	return nil
+}
+
+// ofType returns the recorded events of type t
+func (r *eventRecorder) ofType(t EventType) []Event {
+	var events []Event
+	for _, e := range r.events {
+		if e.Type == t {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func TestBirthEvents(t *testing.T) {
+	w := genWorld()
+	r := &eventRecorder{}
+	w.AddEventSink(r)
+
+	peep1, _ := w.NewPeep("red", Location{1, 1, 0})
+
+	Convey("Births are recorded.", t, func() {
+		births := r.ofType(EventBirth)
+		So(len(births), ShouldEqual, 1)
+		So(births[0].ID, ShouldEqual, peep1.ID())
+		So(births[0].Gender, ShouldEqual, "red")
+		So(*births[0].Location, ShouldResemble, Location{1, 1, 0})
+	})
+
+	w.settings.MaxPeeps = 1
+	w.NewPeep("red", Location{2, 2, 0})
+	peep1.age = w.settings.SpawnAge
+	peep2 := &Peep{id: "peep2", gender: "red", isalive: true, age: w.settings.SpawnAge, world: w, met: make(map[Exister]Turn), genome: w.settings.defaultGenome()}
+	w.UpdateGrid(peep2, Location{1, 2, 0}, Location{1, 2, 0})
+	w.SameGenderSpawn(peep1, peep2)
+
+	Convey("Blocked spawns are recorded.", t, func() {
+		blocked := r.ofType(EventSpawnBlocked)
+		So(len(blocked), ShouldEqual, 1)
+		So(blocked[0].ID, ShouldEqual, peep1.ID())
+		So(blocked[0].Other, ShouldEqual, "peep2")
+		So(blocked[0].Reason, ShouldNotBeEmpty)
+	})
+}
+
+func TestDeathEvents(t *testing.T) {
+	w := genWorld()
+	w.settings.KillIfSurrounded = true
+	r := &eventRecorder{}
+	w.AddEventSink(r)
+
+	old, _ := w.NewPeep("red", Location{5, 5, 0})
+	old.age = w.settings.MaxAge
+
+	surrounded, _ := w.NewPeep("red", Location{w.MinX(), w.MinY(), 0})
+	for _, l := range w.LocationNeighbors(surrounded.Location(), 1) {
+		w.NewPeep("blue", l)
+	}
+
+	w.NextTurn()
+
+	Convey("Deaths are recorded with their cause.", t, func() {
+		deaths := r.ofType(EventDeath)
+		So(len(deaths), ShouldEqual, 2)
+		So(deaths[0].ID, ShouldEqual, surrounded.ID())
+		So(deaths[0].Cause, ShouldEqual, DeathSurrounded)
+		So(deaths[1].ID, ShouldEqual, old.ID())
+		So(deaths[1].Cause, ShouldEqual, DeathOldAge)
+		So(old.CauseOfDeath(), ShouldEqual, DeathOldAge)
+	})
+
+	Convey("Random spawns that are not tried are not recorded as blocked.", t, func() {
+		So(w.AlivePeepCount(), ShouldBeGreaterThanOrEqualTo, w.settings.NewPeepMax)
+		So(r.ofType(EventSpawnBlocked), ShouldBeEmpty)
+	})
+
+	Convey("Turn events are only for the last turn.", t, func() {
+		So(w.TurnEvents()[len(w.TurnEvents())-1].Type, ShouldEqual, EventTurn)
+		for _, e := range w.TurnEvents() {
+			So(e.Turn, ShouldEqual, 1)
+		}
+	})
+}
+
+func TestMeetAndMoveEvents(t *testing.T) {
+	w := genWorld()
+	r := &eventRecorder{}
+	w.AddEventSink(r)
+
+	peep1, _ := w.NewPeep("red", Location{3, 4, 0})
+	peep2, _ := w.NewPeep("blue", Location{5, 4, 0})
+
+	w.Move(peep1, 1, 0, 0)
+	w.Move(peep1, 1, 0, 0)
+
+	Convey("Moves and meetings are recorded.", t, func() {
+		moves := r.ofType(EventMove)
+		So(len(moves), ShouldEqual, 1)
+		So(*moves[0].From, ShouldResemble, Location{3, 4, 0})
+		So(*moves[0].Location, ShouldResemble, Location{4, 4, 0})
+
+		meetings := r.ofType(EventMeet)
+		So(len(meetings), ShouldEqual, 1)
+		So(meetings[0].ID, ShouldEqual, peep1.ID())
+		So(meetings[0].Other, ShouldEqual, peep2.ID())
+	})
+}
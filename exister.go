@@ -0,0 +1,95 @@
+package world
+
+// Exister is anything on the grid.
+// What else it can do is told by the capability interfaces it implements: Living, Reproducer, Perceiver and Social.
+// World code type-asserts on them, so things like rocks or buildings only need to be Locatable.
+type Exister interface {
+	Locatable
+}
+
+// Locatable is something with a place on the grid
+type Locatable interface {
+	ID() string
+	Location() Location // location of the exister on the map
+	World() *World      // returns pointer to the World this exister inhabits
+}
+
+// Living is an exister that ages, spends energy and dies
+type Living interface {
+	Exister
+	Age() PeepAge
+	IsAlive() bool
+	DeadAtTurn() Turn
+	CauseOfDeath() DeathCause
+	Energy() float64   // energy left, see Settings.FoodPatches
+	SetEnergy(float64) // sets the energy left
+	Genome() Genome    // inherited traits, see Genome
+}
+
+// Reproducer is a living exister that spawns new ones
+type Reproducer interface {
+	Living
+	SpawnTurn() Turn   // last time exister spawned
+	SetSpawnTurn(Turn) // sets the spawn turn
+}
+
+// Perceiver is an exister that looks around and remembers what it saw
+type Perceiver interface {
+	Exister
+	SetLookTurn(Turn)                        // sets the turn the exister looked around
+	LookTurn() Turn                          // last time exister looked around
+	SetNeighbors()                           // sets the neighbors around the exister on this turn
+	NeighborsFromLook() map[Location]Exister // gets the neighbors of the exister (from the last look time)
+}
+
+// Social is an exister with a gender and a homebase, that keeps track of who it met
+type Social interface {
+	Exister
+	Gender() PeepGender
+	Homebase() Location
+	Met() map[Exister]Turn // Map of exister to turn when met
+	Meet(Exister, Turn)
+	MetPeep(Exister) bool // Whether the two have met
+}
+
+// isAlive returns true if e is living and alive
+func isAlive(e Exister) bool {
+	l, ok := e.(Living)
+	return ok && l.IsAlive()
+}
+
+// isPresent returns true if e takes up its cell: things that don't live always do, living ones until they die
+func isPresent(e Exister) bool {
+	l, ok := e.(Living)
+	return !ok || l.IsAlive()
+}
+
+// objectIcon is how existers that are not peeps or predators are drawn, see ExisterIcon
+const objectIcon = '*'
+
+// mate is an exister that can spawn peeps: social, to meet others, and a reproducer. Peeps are mates.
+type mate interface {
+	Social
+	Reproducer
+}
+
+// age returns the age of e, 0 if it doesn't live
+func age(e Exister) PeepAge {
+	if l, ok := e.(Living); ok {
+		return l.Age()
+	}
+	return 0
+}
+
+// genome returns the genome of e, the zero genome if it doesn't live
+func genome(e Exister) Genome {
+	if l, ok := e.(Living); ok {
+		return l.Genome()
+	}
+	return Genome{}
+}
+
+// viewDistance returns how far e sees, see Genome.ViewDistance
+func viewDistance(e Exister) int32 {
+	return genome(e).ViewDistance
+}
@@ -0,0 +1,95 @@
+package world
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// rock is only Locatable: it doesn't live, look around or meet anyone
+type rock struct {
+	id    string
+	world *World
+}
+
+func (r *rock) ID() string         { return r.id }
+func (r *rock) World() *World      { return r.world }
+func (r *rock) Location() Location { l, _ := r.world.ExisterLocation(r); return l }
+
+func TestCapabilities(t *testing.T) {
+	Convey("Peeps can do everything, predators all but socialize.", t, func() {
+		So(&Peep{}, ShouldImplement, (*Living)(nil))
+		So(&Peep{}, ShouldImplement, (*Reproducer)(nil))
+		So(&Peep{}, ShouldImplement, (*Perceiver)(nil))
+		So(&Peep{}, ShouldImplement, (*Social)(nil))
+
+		So(&Predator{}, ShouldImplement, (*Reproducer)(nil))
+		So(&Predator{}, ShouldImplement, (*Perceiver)(nil))
+		So(&Predator{}, ShouldNotImplement, (*Social)(nil))
+	})
+}
+
+func TestMixedExisters(t *testing.T) {
+	enableMoves(t)
+
+	w := genWorld()
+	w.SetHomebase("red", Location{-9, -9, 0})
+	peep, _ := w.NewPeep("red", Location{2, 0, 0})
+	predator, _ := w.NewPredator(Location{5, 5, 0})
+	stone := &rock{id: "rock", world: w}
+	w.UpdateGrid(stone, Location{1, 0, 0}, Location{1, 0, 0})
+	w.Publish()
+
+	Convey("Rocks take up their cell and are not counted as peeps.", t, func() {
+		So(w.IsOccupiedLocation(Location{1, 0, 0}), ShouldBeTrue)
+		_, err := w.NewPeep("red", Location{1, 0, 0})
+		So(err, ShouldNotBeNil)
+		So(w.Move(peep, -1, 0, 0), ShouldNotBeNil)
+		So(peep.Met(), ShouldBeEmpty)
+
+		So(w.AlivePeepCount(), ShouldEqual, 1)
+		So(w.AlivePredatorCount(), ShouldEqual, 1)
+		So(w.View().Peeps, ShouldHaveLength, 1)
+
+		var info WorldInfo
+		So(get(w, "/api/world", &info), ShouldEqual, http.StatusOK)
+		So(info.Alive, ShouldEqual, 1)
+	})
+
+	Convey("Peeps don't see rocks, and rocks don't act.", t, func() {
+		w.LookAround(peep)
+		So(peep.NeighborsFromLook(), ShouldNotContainKey, Location{1, 0, 0})
+		_, _, _, err := w.decideMove(stone, w.random)
+		So(err, ShouldNotBeNil)
+
+		w.NextTurn()
+		w.NextTurn()
+		So(w.LocationExister(Location{1, 0, 0}), ShouldEqual, stone)
+		_, err = w.Decisions(stone.ID())
+		So(err, ShouldNotBeNil)
+		_, err = w.Decisions(predator.ID())
+		So(err, ShouldBeNil)
+	})
+
+	Convey("Rocks are drawn, and left out of snapshots.", t, func() {
+		var cell Cell
+		for _, c := range w.Frame().Cells {
+			if c.ID == stone.ID() {
+				cell = c
+			}
+		}
+		So(cell.Icon, ShouldEqual, objectIcon)
+		So(cell.Dead, ShouldBeFalse)
+		So(cellVisuals(cell).Char, ShouldEqual, objectIcon)
+		So(w.ExisterVisuals(stone).Char, ShouldEqual, objectIcon)
+
+		var saved bytes.Buffer
+		So(w.Snapshot(&saved), ShouldBeNil)
+		restored, err := LoadWorld(bytes.NewReader(saved.Bytes()), false)
+		So(err, ShouldBeNil)
+		So(restored.LocationExister(Location{1, 0, 0}), ShouldBeNil)
+		So(restored.AlivePeepCount(), ShouldEqual, w.AlivePeepCount())
+	})
+}
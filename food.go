@@ -0,0 +1,173 @@
+package world
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+)
+
+// FoodPatch is food lying on the ground. Peeps walk over it and eat from it, and it regrows every turn.
+type FoodPatch struct {
+	Location Location `json:"location"`
+	Amount   float64  `json:"amount"`
+}
+
+// foodEnabled returns true if peeps need food to live, see Settings.FoodPatches
+func (s Settings) foodEnabled() bool {
+	return s.FoodPatches > 0
+}
+
+// placeFood adds full patches at random passable places inside the border, until there are Settings.FoodPatches
+func (w *World) placeFood() {
+	cells := int64(w.MaxX()-w.MinX()+1) * int64(w.MaxY()-w.MinY()+1) * int64(w.MaxZ()-w.MinZ()+1)
+	for _, c := range w.terrainCells {
+		if !c.Terrain.Passable() {
+			cells--
+		}
+	}
+	for n := int64(len(w.food)); n < int64(w.settings.FoodPatches) && n < cells; {
+		l := Location{
+			X: w.MinX() + w.random.Int31n(w.MaxX()-w.MinX()+1),
+			Y: w.MinY() + w.random.Int31n(w.MaxY()-w.MinY()+1),
+			Z: w.MinZ() + w.random.Int31n(w.MaxZ()-w.MinZ()+1),
+		}
+		if _, ok := w.food[l]; ok || w.IsBlocked(l.X, l.Y, l.Z) {
+			continue
+		}
+		w.food[l] = &FoodPatch{Location: l, Amount: w.settings.FoodMax}
+		n++
+	}
+}
+
+// SetFood puts a patch with amount food at l, or replaces the one there. An amount of 0 leaves a patch that regrows.
+func (w *World) SetFood(l Location, amount float64) error {
+	if w.IsBlocked(l.X, l.Y, l.Z) {
+		return fmt.Errorf("Location %v is outside the grid or impassable", l)
+	}
+	w.food[l] = &FoodPatch{Location: l, Amount: math.Min(amount, w.settings.FoodMax)}
+	return nil
+}
+
+// RemoveFood removes the patch at l, if any
+func (w *World) RemoveFood(l Location) {
+	delete(w.food, l)
+}
+
+// Food returns all food patches, ordered by location
+func (w *World) Food() []FoodPatch {
+	food := make([]FoodPatch, 0, len(w.food))
+	for _, f := range w.food {
+		food = append(food, *f)
+	}
+	sort.Slice(food, func(i, j int) bool {
+		return food[i].Location.Less(food[j].Location)
+	})
+	return food
+}
+
+// regrowFood grows every patch by Settings.FoodRegrowth, up to Settings.FoodMax
+func (w *World) regrowFood() {
+	for _, f := range w.food {
+		f.Amount = math.Min(f.Amount+w.settings.FoodRegrowth, w.settings.FoodMax)
+	}
+}
+
+// nearestFood returns the closest patch with food at most d steps from l, nil if there is none.
+// Ties go to the lowest location.
+func (w *World) nearestFood(l Location, d int32) *FoodPatch {
+	var nearest *FoodPatch
+	for _, f := range w.food {
+		fd := w.distance(l, f.Location)
+		if f.Amount <= 0 || fd > d {
+			continue
+		}
+		if nearest == nil {
+			nearest = f
+			continue
+		}
+		nd := w.distance(l, nearest.Location)
+		if fd < nd || (fd == nd && f.Location.Less(nearest.Location)) {
+			nearest = f
+		}
+	}
+	return nearest
+}
+
+// spendEnergy takes energy from e, if it is a peep and peeps need food.
+// Predators live on their prey alone, see agePredator.
+func (w *World) spendEnergy(e Exister, energy float64) {
+	if p, ok := e.(*Peep); ok && w.settings.foodEnabled() {
+		p.SetEnergy(p.Energy() - energy)
+	}
+}
+
+// starve kills peeps that ran out of energy, it returns true if p died
+func (w *World) starve(p *Peep) bool {
+	if !w.settings.foodEnabled() || !p.IsAlive() || p.Energy() > 0 {
+		return false
+	}
+	p.dieOf(w.turn, DeathStarvation)
+	return true
+}
+
+// hungry returns true if e has less than half its energy left
+func (w *World) hungry(e Exister) bool {
+	l, ok := e.(Living)
+	return ok && w.settings.foodEnabled() && l.Energy() < w.settings.MaxEnergy/2
+}
+
+// hunger is how empty a peep is, 0 when full of energy and 1 when about to starve
+var hunger = Consideration{"hunger", func(w *World, e Exister) float64 {
+	l, ok := e.(Living)
+	if !ok || !w.settings.foodEnabled() || w.settings.MaxEnergy <= 0 {
+		return 0
+	}
+	return 1 - l.Energy()/w.settings.MaxEnergy
+}}
+
+// foodInReach is 1 if there is food on or next to a peep
+var foodInReach = Consideration{"food_in_reach", func(w *World, e Exister) float64 {
+	if w.nearestFood(e.Location(), 1) == nil {
+		return 0
+	}
+	return 1
+}}
+
+// eatAction makes a peep eat from the closest patch on or next to it
+type eatAction struct{}
+
+// Name implements Action
+func (eatAction) Name() string { return "eat" }
+
+// Score implements Action, the hungrier a peep the more it wants to eat. Predators only eat peeps.
+func (eatAction) Score(w *World, e Exister) Score {
+	if !isPeep(e) {
+		return Score{Considerations: []Rating{{"eats_food", 0}}}
+	}
+	return Utility(w, e, 1, hunger, foodInReach)
+}
+
+// Execute implements Action
+func (eatAction) Execute(w *World, e Exister) error {
+	l, ok := e.(Living)
+	if !ok {
+		return fmt.Errorf("%v does not eat", e.ID())
+	}
+	f := w.nearestFood(e.Location(), 1)
+	if f == nil {
+		return fmt.Errorf("no food next to %v", e.ID())
+	}
+	eaten := math.Min(math.Min(w.settings.EatAmount, f.Amount), w.settings.MaxEnergy-l.Energy())
+	if eaten <= 0 {
+		return nil
+	}
+	f.Amount -= eaten
+	l.SetEnergy(l.Energy() + eaten)
+	return nil
+}
+
+// FoodHandler serves all food patches
+func (w *World) FoodHandler(writer http.ResponseWriter, r *http.Request) {
+	writeJSON(writer, w.View().Food)
+}
@@ -0,0 +1,157 @@
+package world
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// genFoodWorld returns a seeded world where peeps need food
+func genFoodWorld(seed int64) *World {
+	s := genSeededWorld(seed).settings
+	s.FoodPatches = 20
+	s.FoodMax = 10
+	s.FoodRegrowth = 0.5
+	s.MaxEnergy = 20
+	s.MoveEnergy = 0.5
+	s.TurnEnergy = 0.5
+	s.EatAmount = 5
+	w := NewWorld("Fed", s, false)
+	w.SetDefaultHomebases()
+	return w
+}
+
+func TestFood(t *testing.T) {
+	w := genFoodWorld(1)
+
+	Convey("Full patches are placed when the world is created.", t, func() {
+		food := w.Food()
+		So(len(food), ShouldEqual, 20)
+		for i, f := range food {
+			So(f.Amount, ShouldEqual, 10)
+			So(w.IsOutsideGrid(f.Location.X, f.Location.Y, f.Location.Z), ShouldBeFalse)
+			if i > 0 {
+				So(food[i-1].Location.Less(f.Location), ShouldBeTrue)
+			}
+		}
+		So(genWorld().Food(), ShouldBeEmpty)
+	})
+
+	Convey("Patches regrow up to their maximum.", t, func() {
+		So(w.SetFood(Location{1, 1, 0}, 9), ShouldBeNil)
+		w.regrowFood()
+		So(w.nearestFood(Location{1, 1, 0}, 0).Amount, ShouldEqual, 9.5)
+		w.regrowFood()
+		w.regrowFood()
+		So(w.nearestFood(Location{1, 1, 0}, 0).Amount, ShouldEqual, 10)
+
+		So(w.SetFood(Location{100, 1, 0}, 1), ShouldNotBeNil)
+	})
+
+	Convey("Food is served.", t, func() {
+		w.Publish()
+		var food []FoodPatch
+		So(get(w, "/api/food", &food), ShouldEqual, http.StatusOK)
+		So(food, ShouldResemble, w.Food())
+	})
+}
+
+func TestEnergy(t *testing.T) {
+	w := genFoodWorld(1)
+	for _, f := range w.Food() {
+		w.RemoveFood(f.Location)
+	}
+	w.settings.NewPeepMax = 0
+	r := &eventRecorder{}
+	w.AddEventSink(r)
+
+	p, _ := w.NewPeep("red", Location{1, 1, 0})
+
+	Convey("Peeps are born full of energy, and spend it living and moving.", t, func() {
+		So(p.Energy(), ShouldEqual, 20)
+		w.NextTurn()
+		So(p.Energy(), ShouldEqual, 19.5)
+		So(w.Move(p, 1, 0, 0), ShouldBeNil)
+		So(p.Energy(), ShouldEqual, 19)
+	})
+
+	Convey("Hungry peeps eat the food next to them.", t, func() {
+		p.SetEnergy(8)
+		w.SetFood(Location{3, 1, 0}, 10)
+		scores := w.scoreActions(p)
+		So(scores[0].Action, ShouldEqual, "eat")
+		So(scores[0].Value, ShouldEqual, 0.6)
+
+		So(eatAction{}.Execute(w, p), ShouldBeNil)
+		So(p.Energy(), ShouldEqual, 13)
+		So(w.nearestFood(p.Location(), 1).Amount, ShouldEqual, 5)
+
+		p.SetEnergy(19)
+		So(eatAction{}.Execute(w, p), ShouldBeNil)
+		So(p.Energy(), ShouldEqual, 20) // no more than MaxEnergy
+		So(w.nearestFood(p.Location(), 1).Amount, ShouldEqual, 4)
+	})
+
+	Convey("Hungry peeps head for food they can see.", t, func() {
+		w.RemoveFood(Location{3, 1, 0})
+		w.SetFood(Location{2, 3, 0}, 10) // two steps away, within view
+		p.SetEnergy(5)
+		x, y, z := w.bestPeepMove(p, nil, w.random)
+		So([]int32{x, y, z}, ShouldResemble, []int32{0, 1, 0})
+	})
+
+	Convey("Peeps without energy starve.", t, func() {
+		p.SetEnergy(0.5)
+		w.NextTurn()
+		So(p.IsAlive(), ShouldBeFalse)
+		So(p.CauseOfDeath(), ShouldEqual, DeathStarvation)
+		deaths := r.ofType(EventDeath)
+		So(deaths[len(deaths)-1].Cause, ShouldEqual, DeathStarvation)
+	})
+}
+
+func TestStarvation(t *testing.T) {
+	enableMoves(t)
+
+	w := genFoodWorld(5)
+	w.settings.FoodRegrowth = 0
+	for _, f := range w.Food() {
+		w.RemoveFood(f.Location)
+	}
+	turnHistory(w, 60)
+
+	Convey("Without food, peeps starve and the living still have energy left.", t, func() {
+		var starved int
+		for _, e := range w.allExisters() {
+			p := e.(*Peep)
+			if p.IsAlive() {
+				So(p.Energy(), ShouldBeGreaterThan, 0)
+			} else if p.CauseOfDeath() == DeathStarvation {
+				starved++
+			}
+		}
+		So(starved, ShouldBeGreaterThan, 0)
+	})
+}
+
+func TestFoodSnapshot(t *testing.T) {
+	enableMoves(t)
+
+	original := genFoodWorld(7)
+	turnHistory(original, 50)
+
+	Convey("Food and energy are saved and continue like the original.", t, func() {
+		var saved bytes.Buffer
+		So(original.Snapshot(&saved), ShouldBeNil)
+		restored, err := LoadWorld(bytes.NewReader(saved.Bytes()), false)
+		So(err, ShouldBeNil)
+		So(restored.Food(), ShouldResemble, original.Food())
+
+		turnHistory(restored, 50)
+		turnHistory(original, 50)
+		So(restored.Food(), ShouldResemble, original.Food())
+		So(restored.View().Peeps, ShouldResemble, original.View().Peeps)
+	})
+}
@@ -0,0 +1,163 @@
+package world
+
+import (
+	"math"
+	"math/rand"
+)
+
+// mutationScale is how much a mutation changes a gene at most, as a part of its value.
+// Whole number genes always change by at least 1.
+const mutationScale = 0.1
+
+// lifespanGrowth is how far mutations can stretch lifespans, as a multiple of Settings.MaxAge or Settings.PredatorMaxAge
+const lifespanGrowth = 2
+
+// Genome is what a peep inherits from its parents: its own values for what Settings used to give every peep
+type Genome struct {
+	ViewDistance int32   `json:"view_distance"` // how far the peep sees, see Settings.PeepViewDistance
+	Lifespan     PeepAge `json:"lifespan"`      // the peep cannot live beyond this age, see Settings.MaxAge and maxLifespan
+	Fertility    float64 `json:"fertility"`     // chances of spawning when meeting a mate, see Settings.SpawnProbability
+	Speed        float64 `json:"speed"`         // chances of moving on a turn, in [0, 1]
+	Sociability  float64 `json:"sociability"`   // chances of heading for another peep it sees, in [0, 1]
+}
+
+// genes are the names of the genes of a Genome, in the order of Genome.values
+var genes = []string{"view_distance", "lifespan", "fertility", "speed", "sociability"}
+
+// defaultGenome returns the genome of peeps without parents, taken from the settings
+func (s Settings) defaultGenome() Genome {
+	return Genome{
+		ViewDistance: s.PeepViewDistance,
+		Lifespan:     s.MaxAge,
+		Fertility:    s.SpawnProbability,
+		Speed:        1,
+		Sociability:  1,
+	}
+}
+
+// maxLifespan returns the longest lifespan mutations can reach from base, see lifespanGrowth
+func maxLifespan(base PeepAge) PeepAge {
+	return lifespanGrowth * base
+}
+
+// values returns the genes of g in the order of genes
+func (g Genome) values() []float64 {
+	return []float64{float64(g.ViewDistance), float64(g.Lifespan), g.Fertility, g.Speed, g.Sociability}
+}
+
+// genomeOf returns the genome with the genes in values, in the order of genes, kept within their bounds
+func genomeOf(values []float64) Genome {
+	return Genome{
+		ViewDistance: int32(math.Max(0, math.Round(values[0]))),
+		Lifespan:     PeepAge(math.Max(1, math.Round(values[1]))),
+		Fertility:    clamp(values[2]),
+		Speed:        clamp(values[3]),
+		Sociability:  clamp(values[4]),
+	}
+}
+
+// inherit returns the genome of a child of parents.
+// Each gene comes from one of the parents at random, then mutates with chances Settings.MutationRate.
+// Peeps without parents get the default genome.
+func (w *World) inherit(parents []Exister) Genome {
+	if len(parents) == 0 {
+		return w.settings.defaultGenome()
+	}
+
+	values := genome(parents[0]).values()
+	for i := range values {
+		var differ bool
+		for _, p := range parents[1:] {
+			differ = differ || genome(p).values()[i] != values[i]
+		}
+		if differ { // only draw when it matters, so worlds without mutations play out as they always did
+			values[i] = genome(parents[w.random.Intn(len(parents))]).values()[i]
+		}
+	}
+
+	if w.settings.MutationRate > 0 {
+		for i := range values {
+			if w.random.Float64() < w.settings.MutationRate {
+				values[i] = mutate(values[i], i < 2, w.random)
+			}
+		}
+	}
+
+	g := genomeOf(values)
+	base := w.settings.MaxAge
+	if isPredator(parents[0]) {
+		base = w.settings.PredatorMaxAge
+	}
+	if g.Lifespan > maxLifespan(base) {
+		g.Lifespan = maxLifespan(base)
+	}
+	return g
+}
+
+// mutate returns v changed by up to mutationScale of it, up or down
+func mutate(v float64, whole bool, rng *rand.Rand) float64 {
+	delta := v * mutationScale * (2*rng.Float64() - 1)
+	if !whole {
+		return v + delta
+	}
+	step := math.Round(delta)
+	if step == 0 {
+		step = 1
+		if delta < 0 {
+			step = -1
+		}
+	}
+	return v + step
+}
+
+// GenomeStats is the average genome of the alive peeps
+type GenomeStats struct {
+	ViewDistance float64 `json:"view_distance"`
+	Lifespan     float64 `json:"lifespan"`
+	Fertility    float64 `json:"fertility"`
+	Speed        float64 `json:"speed"`
+	Sociability  float64 `json:"sociability"`
+}
+
+// values returns the averages of s in the order of genes
+func (s GenomeStats) values() []float64 {
+	return []float64{s.ViewDistance, s.Lifespan, s.Fertility, s.Speed, s.Sociability}
+}
+
+// GenomeStats returns the average genome of the alive peeps, all zero if there are none
+func (w *World) GenomeStats() GenomeStats {
+	sums := make([]float64, len(genes))
+	var alive float64
+	for _, e := range w.grid.Occupants() { // in order, so sums come out the same every time
+		p, ok := e.(*Peep)
+		if !ok || !p.IsAlive() {
+			continue
+		}
+		for i, v := range p.Genome().values() {
+			sums[i] += v
+		}
+		alive++
+	}
+	if alive == 0 {
+		return GenomeStats{}
+	}
+	for i := range sums {
+		sums[i] /= alive
+	}
+	return GenomeStats{sums[0], sums[1], sums[2], sums[3], sums[4]}
+}
+
+// sociable returns true if e heads for the peeps it sees this time, see Genome.Sociability
+func sociable(e Exister, rng *rand.Rand) bool {
+	s := genome(e).Sociability
+	return s >= 1 || rng.Float64() < s
+}
+
+// fast is 1 if a peep moves this turn, see Genome.Speed
+var fast = Consideration{"speed", func(w *World, e Exister) float64 {
+	s := genome(e).Speed
+	if s >= 1 || w.TurnRandom(e, "speed").Float64() < s {
+		return 1
+	}
+	return 0
+}}
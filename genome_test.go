@@ -0,0 +1,170 @@
+package world
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInherit(t *testing.T) {
+	w := genWorld()
+	mother, _ := w.NewPeep("red", Location{1, 1, 0})
+	father, _ := w.NewPeep("red", Location{2, 1, 0})
+
+	Convey("Peeps without parents get their genome from the settings.", t, func() {
+		So(mother.Genome(), ShouldResemble, Genome{ViewDistance: 2, Lifespan: 10, Fertility: 1, Speed: 1, Sociability: 1})
+	})
+
+	Convey("Without mutations, children get every gene from one of their parents.", t, func() {
+		mother.genome = Genome{ViewDistance: 1, Lifespan: 8, Fertility: 0.2, Speed: 0.5, Sociability: 0}
+		father.genome = Genome{ViewDistance: 3, Lifespan: 12, Fertility: 0.8, Speed: 1, Sociability: 1}
+		type gene struct {
+			i int
+			v float64
+		}
+		seen := make(map[gene]bool)
+		for i := 0; i < 20; i++ {
+			child := w.inherit([]Exister{mother, father})
+			for g, v := range child.values() {
+				So(v, ShouldBeIn, mother.genome.values()[g], father.genome.values()[g])
+				seen[gene{g, v}] = true
+			}
+		}
+		So(len(seen), ShouldEqual, 10) // both parents passed on every gene
+
+		So(w.inherit([]Exister{mother, mother}), ShouldResemble, mother.genome)
+	})
+
+	Convey("Mutations change genes a little, within their bounds.", t, func() {
+		w.settings.MutationRate = 1
+		father.genome = Genome{ViewDistance: 3, Lifespan: 12, Fertility: 0.5, Speed: 1, Sociability: 0}
+		for i := 0; i < 20; i++ {
+			child := w.inherit([]Exister{father, father})
+			So(child.ViewDistance, ShouldBeIn, int32(2), int32(4))
+			So(child.Lifespan, ShouldBeIn, PeepAge(11), PeepAge(13))
+			So(child.Fertility, ShouldBeBetweenOrEqual, 0.45, 0.55)
+			So(child.Fertility, ShouldNotEqual, 0.5)
+			So(child.Speed, ShouldBeBetweenOrEqual, 0.9, 1)
+			So(child.Sociability, ShouldEqual, 0)
+		}
+	})
+
+	Convey("Lifespans don't grow beyond their longest.", t, func() {
+		father.genome.Lifespan = maxLifespan(w.settings.MaxAge)
+		for i := 0; i < 20; i++ {
+			So(w.inherit([]Exister{father, father}).Lifespan, ShouldBeLessThanOrEqualTo, maxLifespan(w.settings.MaxAge))
+		}
+	})
+}
+
+func TestGenomeInUse(t *testing.T) {
+	w := genWorld()
+	w.settings.PeepSpawnInterval = 0
+	p, _ := w.NewPeep("red", Location{1, 1, 0})
+	mate, _ := w.NewPeep("red", Location{3, 1, 0})
+	p.age, mate.age = w.settings.SpawnAge, w.settings.SpawnAge
+
+	Convey("Peeps see as far as their genome lets them.", t, func() {
+		p.SetNeighbors()
+		So(p.NeighborsFromLook(), ShouldContainKey, mate.Location())
+
+		p.neighbors = make(map[Location]Exister)
+		p.genome.ViewDistance = 1
+		p.SetNeighbors()
+		So(p.NeighborsFromLook(), ShouldNotContainKey, mate.Location())
+		So(w.visibleNeighbors(p), ShouldBeEmpty)
+	})
+
+	Convey("Peeps head for mates only when sociable.", t, func() {
+		p.genome.ViewDistance = 2
+		x, y, z := w.bestPeepMove(p, w.visibleNeighbors(p), w.random)
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, 0, 0})
+
+		p.genome.Sociability = 0
+		enableMoves(t)
+		var toMate int
+		for i := 0; i < 20; i++ {
+			if x, y, _ := w.bestPeepMove(p, w.visibleNeighbors(p), w.random); x == 1 && y == 0 {
+				toMate++
+			}
+		}
+		So(toMate, ShouldBeLessThan, 20)
+		p.genome.Sociability = 1
+	})
+
+	Convey("Slow peeps don't move every turn.", t, func() {
+		enableMoves(t)
+		So(w.action("move").Score(w, p).Value, ShouldBeGreaterThan, 0)
+		p.genome.Speed = 0
+		So(w.action("move").Score(w, p).Value, ShouldEqual, 0)
+	})
+
+	Convey("Infertile peeps don't spawn.", t, func() {
+		p.genome.Fertility, mate.genome.Fertility = 0, 0
+		before := w.AlivePeepCount()
+		So(w.SameGenderSpawn(p, mate), ShouldBeNil)
+		So(w.AlivePeepCount(), ShouldEqual, before)
+
+		mate.genome.Fertility = 1
+		w.random.Seed(1)
+		for i := 0; i < 10 && w.AlivePeepCount() == before; i++ {
+			w.SameGenderSpawn(p, mate)
+		}
+		So(w.AlivePeepCount(), ShouldEqual, before+1)
+	})
+
+	Convey("Peeps die of old age at the end of their own lifespan.", t, func() {
+		p.genome.Lifespan = p.age + 1
+		w.NextTurn()
+		So(p.IsAlive(), ShouldBeTrue)
+		w.NextTurn()
+		So(p.IsAlive(), ShouldBeFalse)
+		So(p.CauseOfDeath(), ShouldEqual, DeathOldAge)
+		So(mate.IsAlive(), ShouldBeTrue)
+	})
+}
+
+func TestGenomeStats(t *testing.T) {
+	w := genWorld()
+	a, _ := w.NewPeep("red", Location{1, 1, 0})
+	b, _ := w.NewPeep("red", Location{5, 1, 0})
+	a.genome = Genome{ViewDistance: 1, Lifespan: 10, Fertility: 0.2, Speed: 1, Sociability: 0.5}
+	b.genome = Genome{ViewDistance: 3, Lifespan: 20, Fertility: 0.4, Speed: 0.5, Sociability: 0.5}
+	w.Publish()
+
+	Convey("The average genome is reported.", t, func() {
+		want := GenomeStats{ViewDistance: 2, Lifespan: 15, Fertility: 0.30000000000000004, Speed: 0.75, Sociability: 0.5}
+		So(w.GenomeStats(), ShouldResemble, want)
+
+		var info WorldInfo
+		So(get(w, "/api/world", &info), ShouldEqual, http.StatusOK)
+		So(info.Genome, ShouldResemble, want)
+
+		var peep PeepDetail
+		So(get(w, "/api/peeps/"+a.ID(), &peep), ShouldEqual, http.StatusOK)
+		So(peep.Genome, ShouldResemble, a.genome)
+	})
+}
+
+func TestGenomeSnapshot(t *testing.T) {
+	enableMoves(t)
+
+	original := genSeededWorld(13)
+	original.settings.MutationRate = 0.5
+	turnHistory(original, 100)
+
+	Convey("Genomes are saved and the world evolves like the original.", t, func() {
+		So(original.GenomeStats(), ShouldNotResemble, GenomeStats{})
+
+		var saved bytes.Buffer
+		So(original.Snapshot(&saved), ShouldBeNil)
+		restored, err := LoadWorld(bytes.NewReader(saved.Bytes()), false)
+		So(err, ShouldBeNil)
+		So(restored.GenomeStats(), ShouldResemble, original.GenomeStats())
+
+		So(turnHistory(restored, 100), ShouldResemble, turnHistory(original, 100))
+		So(restored.GenomeStats(), ShouldResemble, original.GenomeStats())
+	})
+}
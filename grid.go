@@ -0,0 +1,297 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// gridBucketSize is the width and height of the square buckets the grid is indexed in
+const gridBucketSize = 16
+
+// bucketKey identifies one bucket of the grid
+type bucketKey struct {
+	x, y, z int32
+}
+
+// Grid holds information about what object is in what cell in the world.
+// Each cell holds at most one object, and each object is in at most one cell.
+// Occupied cells are indexed in square buckets, so box, radius and nearest queries
+// only visit the buckets around the query instead of every cell.
+type Grid struct {
+	lock      sync.RWMutex
+	cells     map[Location]Exister
+	locations map[Exister]Location
+	buckets   map[bucketKey]map[Location]Exister
+}
+
+// NewGrid returns an empty grid
+func NewGrid() *Grid {
+	return &Grid{
+		cells:     make(map[Location]Exister),
+		locations: make(map[Exister]Location),
+		buckets:   make(map[bucketKey]map[Location]Exister),
+	}
+}
+
+// floorDiv divides rounding towards negative infinity, so that buckets don't straddle 0
+func floorDiv(a, b int32) int32 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// bucketOf returns the bucket l is indexed in
+func bucketOf(l Location) bucketKey {
+	return bucketKey{floorDiv(l.X, gridBucketSize), floorDiv(l.Y, gridBucketSize), l.Z}
+}
+
+// At returns the exister at l, or nil if the cell is empty
+func (g *Grid) At(l Location) Exister {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.cells[l]
+}
+
+// LocationOf returns the location of e
+func (g *Grid) LocationOf(e Exister) (Location, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	l, ok := g.locations[e]
+	if !ok {
+		return Location{}, fmt.Errorf("No such exister on the map!")
+	}
+	return l, nil
+}
+
+// Len returns the number of occupied cells
+func (g *Grid) Len() int {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return len(g.cells)
+}
+
+// Set puts e at l. If e was elsewhere it is moved; whoever was at l is taken off the grid.
+func (g *Grid) Set(e Exister, l Location) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if old, ok := g.locations[e]; ok {
+		g.remove(e, old)
+	}
+	if other, ok := g.cells[l]; ok {
+		g.remove(other, l)
+	}
+
+	g.cells[l] = e
+	g.locations[e] = l
+	b := bucketOf(l)
+	if g.buckets[b] == nil {
+		g.buckets[b] = make(map[Location]Exister)
+	}
+	g.buckets[b][l] = e
+}
+
+// Remove takes e off the grid
+func (g *Grid) Remove(e Exister) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if l, ok := g.locations[e]; ok {
+		g.remove(e, l)
+	}
+}
+
+// RemoveAt takes whoever is at l off the grid
+func (g *Grid) RemoveAt(l Location) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if e, ok := g.cells[l]; ok {
+		g.remove(e, l)
+	}
+}
+
+// remove takes e at l off the grid, the lock must be held
+func (g *Grid) remove(e Exister, l Location) {
+	delete(g.cells, l)
+	delete(g.locations, e)
+
+	b := bucketOf(l)
+	delete(g.buckets[b], l)
+	if len(g.buckets[b]) == 0 {
+		delete(g.buckets, b)
+	}
+}
+
+// Locations returns all occupied locations, sorted (see Location.Less)
+func (g *Grid) Locations() []Location {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	all := make([]Location, 0, len(g.cells))
+	for l := range g.cells {
+		all = append(all, l)
+	}
+	SortLocations(all)
+	return all
+}
+
+// Occupants returns everything on the grid, ordered by location
+func (g *Grid) Occupants() []Exister {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	all := make([]cell, 0, len(g.cells))
+	for l, e := range g.cells {
+		all = append(all, cell{l, e})
+	}
+	return sortedOccupants(all)
+}
+
+// cell is one occupied cell of the grid
+type cell struct {
+	l Location
+	e Exister
+}
+
+// unordered returns everything on the grid, in no particular order
+func (g *Grid) unordered() []Exister {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	all := make([]Exister, 0, len(g.locations))
+	for e := range g.locations {
+		all = append(all, e)
+	}
+	return all
+}
+
+// count returns how many existers on the grid match, match must not use the grid
+func (g *Grid) count(match func(Exister) bool) int64 {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	var n int64
+	for e := range g.locations {
+		if match(e) {
+			n++
+		}
+	}
+	return n
+}
+
+// sortedOccupants returns the existers of cells ordered by location
+func sortedOccupants(cells []cell) []Exister {
+	sort.Slice(cells, func(i, j int) bool {
+		return cells[i].l.Less(cells[j].l)
+	})
+
+	all := make([]Exister, 0, len(cells))
+	for _, c := range cells {
+		all = append(all, c.e)
+	}
+	return all
+}
+
+// Box returns the existers with min <= location <= max on every axis, ordered by location
+func (g *Grid) Box(min, max Location) []Exister {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return sortedOccupants(g.box(min, max))
+}
+
+// Radius returns the existers at most distance away from center on the same Z level, ordered by location.
+// center itself is included. The search goes across the edges that join, see Settings.Topology.
+func (g *Grid) Radius(s Settings, center Location, distance int32) []Exister {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	d2 := int64(distance) * int64(distance)
+	var found []cell
+	for _, box := range s.boxesAround(center, distance) {
+		for _, c := range g.box(box[0], box[1]) {
+			if s.distanceSquared(center, c.l) <= d2 {
+				found = append(found, c)
+			}
+		}
+	}
+	return sortedOccupants(found)
+}
+
+// NearestOfGender returns the alive exister of gender closest to from, at most maxDistance away on the same Z level.
+// from itself is never returned. Ties are broken by location, nil is returned if nothing matches.
+// The search goes across the edges that join, see Settings.Topology.
+func (g *Grid) NearestOfGender(s Settings, from Location, gender PeepGender, maxDistance int32) Exister {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if span := s.Size.MaxX - s.Size.MinX + s.Size.MaxY - s.Size.MinY; maxDistance > span {
+		maxDistance = span // reaches every cell of the grid
+	}
+	max2 := int64(maxDistance) * int64(maxDistance)
+
+	// Search growing squares around from, until the best match is inside the circle the square holds
+	for d := int32(gridBucketSize); ; d *= 2 {
+		if d > maxDistance {
+			d = maxDistance
+		}
+
+		var (
+			best     Exister
+			bestLoc  Location
+			bestDist int64 = -1
+		)
+		for _, box := range s.boxesAround(from, d) {
+			for _, c := range g.box(box[0], box[1]) {
+				sc, ok := c.e.(Social)
+				if c.l == from || !ok || !isAlive(c.e) || sc.Gender() != gender {
+					continue
+				}
+				dist := s.distanceSquared(from, c.l)
+				if dist > max2 {
+					continue
+				}
+				if bestDist < 0 || dist < bestDist || (dist == bestDist && c.l.Less(bestLoc)) {
+					best, bestLoc, bestDist = c.e, c.l, dist
+				}
+			}
+		}
+		if d == maxDistance || (bestDist >= 0 && bestDist <= int64(d)*int64(d)) {
+			return best
+		}
+	}
+}
+
+// box returns the cells inside the box, visiting only the buckets overlapping it.
+// The lock must be held.
+func (g *Grid) box(min, max Location) []cell {
+	var found []cell
+
+	bmin, bmax := bucketOf(min), bucketOf(max)
+	for z := min.Z; z <= max.Z; z++ {
+		for bx := bmin.x; bx <= bmax.x; bx++ {
+			for by := bmin.y; by <= bmax.y; by++ {
+				for l, e := range g.buckets[bucketKey{bx, by, z}] {
+					if l.X < min.X || l.X > max.X || l.Y < min.Y || l.Y > max.Y {
+						continue
+					}
+					found = append(found, cell{l, e})
+				}
+			}
+		}
+	}
+	return found
+}
+
+// distanceSquared returns the squared straight line distance between a and b
+func distanceSquared(a, b Location) int64 {
+	dx, dy, dz := int64(a.X-b.X), int64(a.Y-b.Y), int64(a.Z-b.Z)
+	return dx*dx + dy*dy + dz*dz
+}
@@ -0,0 +1,245 @@
+package world
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func testPeep(id string, gender PeepGender) *Peep {
+	return &Peep{
+		id:      id,
+		isalive: true,
+		gender:  gender,
+	}
+}
+
+func TestGridGet(t *testing.T) {
+	g := NewGrid()
+
+	e := testPeep("test1", "red")
+	bad := testPeep("bad", "red")
+	l := Location{1, 2, 3}
+	notOccupied := Location{1, 2, 4}
+
+	g.Set(e, l)
+
+	Convey("Exister with uid 'test1' exists at location (1, 2, 3) ", t, func() {
+		loc, err := g.LocationOf(e)
+		So(err, ShouldBeNil)
+		So(loc.SameAs(l), ShouldBeTrue)
+	})
+
+	Convey("Location (1, 2, 3) should contain Exister with uid 'test1'", t, func() {
+		So(g.At(l), ShouldEqual, e)
+	})
+
+	Convey("Location (1, 2, 4) should not exist", t, func() {
+		So(g.At(notOccupied), ShouldBeNil)
+	})
+
+	Convey("Exister bad should not exist", t, func() {
+		loc, err := g.LocationOf(bad)
+		So(err, ShouldNotBeNil)
+		So(loc.SameAs(Location{}), ShouldBeTrue)
+	})
+}
+
+func TestGridSet(t *testing.T) {
+	g := NewGrid()
+
+	e := testPeep("test1", "red")
+	other := testPeep("test2", "red")
+	l := Location{1, 2, 3}
+
+	g.Set(e, l)
+
+	Convey("Exister test1 is in the grid at location (1, 2, 3)", t, func() {
+		loc, err := g.LocationOf(e)
+		So(err, ShouldBeNil)
+		So(loc.SameAs(l), ShouldBeTrue)
+		So(g.At(l), ShouldEqual, e)
+	})
+
+	Convey("Setting an exister again moves it.", t, func() {
+		g.Set(e, Location{40, 2, 3})
+		So(g.At(l), ShouldBeNil)
+		So(g.At(Location{40, 2, 3}), ShouldEqual, e)
+		So(g.Len(), ShouldEqual, 1)
+		So(g.Box(Location{0, 0, 3}, Location{20, 20, 3}), ShouldBeEmpty)
+	})
+
+	Convey("Setting an occupied location replaces its occupant.", t, func() {
+		g.Set(other, Location{40, 2, 3})
+		So(g.At(Location{40, 2, 3}), ShouldEqual, other)
+		_, err := g.LocationOf(e)
+		So(err, ShouldNotBeNil)
+		So(g.Len(), ShouldEqual, 1)
+	})
+}
+
+func TestGridRemove(t *testing.T) {
+	g := NewGrid()
+
+	e := testPeep("test1", "red")
+	l := Location{1, 2, 3}
+
+	g.Set(e, l)
+	g.Remove(e)
+	Convey("Exister test1 and location (1,2,3) are nil", t, func() {
+		loc, err := g.LocationOf(e)
+		So(err, ShouldNotBeNil)
+		So(loc.SameAs(Location{}), ShouldBeTrue)
+		So(g.At(l), ShouldBeNil)
+	})
+
+	g.Set(e, l)
+	g.RemoveAt(l)
+	Convey("Exister test1 and location (1,2,3) are nil", t, func() {
+		loc, err := g.LocationOf(e)
+		So(err, ShouldNotBeNil)
+		So(loc.SameAs(Location{}), ShouldBeTrue)
+		So(g.At(l), ShouldBeNil)
+		So(g.buckets, ShouldBeEmpty)
+	})
+}
+
+func TestGridLocations(t *testing.T) {
+	g := NewGrid()
+
+	Convey("All locations empty", t, func() {
+		So(g.Locations(), ShouldBeEmpty)
+		So(g.Occupants(), ShouldBeEmpty)
+	})
+
+	a, b := testPeep("a", "red"), testPeep("b", "red")
+	g.Set(a, Location{5, 2, 0})
+	g.Set(b, Location{-5, 1, 0})
+
+	Convey("Locations and occupants are sorted.", t, func() {
+		So(g.Locations(), ShouldResemble, []Location{{-5, 1, 0}, {5, 2, 0}})
+		So(g.Occupants(), ShouldResemble, []Exister{b, a})
+	})
+}
+
+func TestGridQueries(t *testing.T) {
+	g := NewGrid()
+	s := Settings{Size: &Size{MaxX: 101, MaxY: 101, MinX: -101, MinY: -101}} // -100..100 inside the border
+
+	center := testPeep("center", "red")
+	near := testPeep("near", "blue")
+	corner := testPeep("corner", "blue")
+	far := testPeep("far", "blue")
+	dead := testPeep("dead", "blue")
+	dead.isalive = false
+	otherLevel := testPeep("level", "blue")
+
+	g.Set(center, Location{0, 0, 0})
+	g.Set(near, Location{-3, 0, 0})
+	g.Set(corner, Location{3, 3, 0})
+	g.Set(far, Location{-60, 70, 0})
+	g.Set(dead, Location{1, 1, 0})
+	g.Set(otherLevel, Location{0, 1, 1})
+
+	Convey("Box finds existers across buckets.", t, func() {
+		So(g.Box(Location{-3, -3, 0}, Location{3, 3, 0}), ShouldResemble, []Exister{near, center, dead, corner})
+		So(g.Box(Location{-100, -100, 0}, Location{100, 100, 1}), ShouldHaveLength, 6)
+		So(g.Box(Location{10, 10, 0}, Location{20, 20, 0}), ShouldBeEmpty)
+	})
+
+	Convey("Radius is a circle.", t, func() {
+		So(g.Radius(s, Location{0, 0, 0}, 3), ShouldResemble, []Exister{near, center, dead})
+		So(g.Radius(s, Location{0, 0, 0}, 5), ShouldResemble, []Exister{near, center, dead, corner})
+	})
+
+	Convey("Nearest finds the closest match.", t, func() {
+		So(g.NearestOfGender(s, Location{0, 0, 0}, "blue", 100), ShouldEqual, near)
+		So(g.NearestOfGender(s, Location{-50, 50, 0}, "blue", 100), ShouldEqual, far)
+		So(g.NearestOfGender(s, Location{0, 0, 0}, "blue", 2), ShouldBeNil)
+		So(g.NearestOfGender(s, Location{0, 0, 0}, "red", 100), ShouldBeNil)
+		So(g.NearestOfGender(s, Location{3, 0, 0}, "red", 100), ShouldEqual, center)
+	})
+
+	Convey("Nearest breaks ties by location.", t, func() {
+		g.Set(testPeep("tie", "blue"), Location{3, 0, 0})
+		So(g.NearestOfGender(s, Location{0, 0, 0}, "blue", 100).ID(), ShouldEqual, "near")
+	})
+}
+
+func TestGridQueriesTopology(t *testing.T) {
+	g := NewGrid()
+	west, east, north := testPeep("west", "blue"), testPeep("east", "blue"), testPeep("north", "blue")
+	g.Set(west, Location{-9, 0, 0})
+	g.Set(east, Location{9, 0, 0})
+	g.Set(north, Location{0, 9, 0})
+
+	gen := func(topology Topology) Settings {
+		s := genWorld().settings // -9..9 inside the border
+		s.Topology = topology
+		return s
+	}
+	bounded, torus, cylinder := gen(TopologyBounded), gen(TopologyTorus), gen(TopologyCylinder)
+
+	Convey("Radius goes across the edges that join.", t, func() {
+		So(g.Radius(bounded, Location{9, 0, 0}, 2), ShouldResemble, []Exister{east})
+		So(g.Radius(torus, Location{9, 0, 0}, 2), ShouldResemble, []Exister{west, east})
+		So(g.Radius(cylinder, Location{9, 0, 0}, 2), ShouldResemble, []Exister{west, east})
+		So(g.Radius(torus, Location{0, -9, 0}, 1), ShouldResemble, []Exister{north})
+		So(g.Radius(cylinder, Location{0, -9, 0}, 1), ShouldBeEmpty)
+	})
+
+	Convey("Nearest goes the short way round.", t, func() {
+		So(g.NearestOfGender(bounded, Location{8, 8, 0}, "blue", 100), ShouldEqual, east)
+		So(g.NearestOfGender(torus, Location{-8, -8, 0}, "blue", 100), ShouldEqual, west)
+		So(g.NearestOfGender(torus, Location{0, -8, 0}, "blue", 100), ShouldEqual, north)
+		So(g.NearestOfGender(cylinder, Location{0, -8, 0}, "blue", 100), ShouldNotEqual, north)
+		So(g.NearestOfGender(torus, Location{9, 0, 0}, "blue", 1), ShouldEqual, west)
+		So(g.NearestOfGender(bounded, Location{9, 0, 0}, "blue", 1), ShouldBeNil)
+	})
+
+	Convey("Nearest matches a brute force search in every topology.", t, func() {
+		r := rand.New(rand.NewSource(1))
+		big := NewGrid()
+		for _, topology := range []Topology{TopologyBounded, TopologyTorus, TopologyCylinder} {
+			s := Settings{Size: &Size{MaxX: 201, MaxY: 201, MinX: -201, MinY: -201}, Topology: topology}
+			for i := 0; i < 300; i++ {
+				big.Set(testPeep(fmt.Sprint(topology, i), "red"), Location{r.Int31n(401) - 200, r.Int31n(401) - 200, 0})
+			}
+			all := big.Locations()
+
+			for i := 0; i < 50; i++ {
+				from := Location{r.Int31n(401) - 200, r.Int31n(401) - 200, 0}
+				var want Location
+				best := int64(-1)
+				for _, l := range all {
+					if l == from {
+						continue
+					}
+					if d := s.distanceSquared(from, l); best < 0 || d < best || (d == best && l.Less(want)) {
+						want, best = l, d
+					}
+				}
+				got, _ := big.LocationOf(big.NearestOfGender(s, from, "red", 1000))
+				So(got, ShouldResemble, want)
+			}
+		}
+	})
+}
+
+func BenchmarkGrid(b *testing.B) {
+	g := NewGrid()
+	s := Settings{Size: &Size{MaxX: 501, MaxY: 501, MinX: -501, MinY: -501}}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		g.Set(testPeep(fmt.Sprint(i), genders[i%len(genders)]), Location{r.Int31n(1000) - 500, r.Int31n(1000) - 500, 0})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l := Location{r.Int31n(1000) - 500, r.Int31n(1000) - 500, 0}
+		g.Box(Location{l.X - 3, l.Y - 3, 0}, Location{l.X + 3, l.Y + 3, 0})
+		g.NearestOfGender(s, l, "red", 100)
+	}
+}
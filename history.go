@@ -0,0 +1,203 @@
+package world
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// TurnStats is the state of the population at the end of a turn
+type TurnStats struct {
+	Turn    Turn
+	Alive   int64
+	Births  int64 // peeps born during the turn
+	Deaths  int64 // peeps died during the turn
+	MinAge  PeepAge
+	AvgAge  PeepAge
+	MaxAge  PeepAge
+	Genders map[PeepGender]int64 // alive peeps per gender
+}
+
+// TurnRecorder receives the stats of every turn
+type TurnRecorder interface {
+	RecordTurn(s TurnStats) error
+}
+
+// AddTurnRecorder adds a recorder that receives the stats of every turn from now on
+func (w *World) AddTurnRecorder(r TurnRecorder) {
+	w.turnRecorders = append(w.turnRecorders, r)
+}
+
+// TurnStats returns the stats of the last turn
+func (w *World) TurnStats() TurnStats {
+	s := TurnStats{
+		Turn:    w.turn,
+		Alive:   w.AlivePeepCount(),
+		MinAge:  w.PeepMinAge(),
+		AvgAge:  w.PeepAvgAge(),
+		MaxAge:  w.PeepMaxAge(),
+		Genders: w.PeepGenders(),
+	}
+	for _, e := range w.turnEvents {
+		switch e.Type {
+		case EventBirth:
+			s.Births++
+		case EventDeath:
+			s.Deaths++
+		}
+	}
+	return s
+}
+
+// recordTurn hands the stats of the last turn to all recorders
+func (w *World) recordTurn() {
+	if len(w.turnRecorders) == 0 {
+		return
+	}
+	s := w.TurnStats()
+	for _, r := range w.turnRecorders {
+		if err := r.RecordTurn(s); err != nil {
+			Log("Error recording turn: ", err)
+		}
+	}
+}
+
+// columns returns the values of s in column order, one column per gender after the fixed ones
+func (s TurnStats) columns(genders []PeepGender) []int64 {
+	c := []int64{int64(s.Turn), s.Alive, s.Births, s.Deaths, int64(s.MinAge), int64(s.AvgAge), int64(s.MaxAge)}
+	for _, g := range genders {
+		c = append(c, s.Genders[g])
+	}
+	return c
+}
+
+// turnStatsColumns are the names of the fixed columns
+var turnStatsColumns = []string{"turn", "alive", "births", "deaths", "min_age", "avg_age", "max_age"}
+
+// CSVTurnRecorder writes turn stats as CSV, one row per turn after a header row
+type CSVTurnRecorder struct {
+	writer  *csv.Writer
+	genders []PeepGender
+	header  bool // header was written
+}
+
+// NewCSVTurnRecorder returns a recorder writing to writer, with one column per gender
+func NewCSVTurnRecorder(writer io.Writer, genders []PeepGender) *CSVTurnRecorder {
+	return &CSVTurnRecorder{writer: csv.NewWriter(writer), genders: genders}
+}
+
+// RecordTurn writes one row
+func (r *CSVTurnRecorder) RecordTurn(s TurnStats) error {
+	if !r.header {
+		header := append([]string{}, turnStatsColumns...)
+		for _, g := range r.genders {
+			header = append(header, string(g))
+		}
+		r.writer.Write(header)
+		r.header = true
+	}
+
+	var row []string
+	for _, c := range s.columns(r.genders) {
+		row = append(row, strconv.FormatInt(c, 10))
+	}
+	r.writer.Write(row)
+	r.writer.Flush()
+	return r.writer.Error()
+}
+
+const (
+	binaryTurnStatsMagic   = "WORLDTS"
+	binaryTurnStatsVersion = 1
+)
+
+// BinaryTurnRecorder writes turn stats in a compact binary format, see ReadTurnStats.
+//
+// The format is little endian: the magic "WORLDTS", a version byte, a gender count byte,
+// each gender name as a length byte and its bytes, then one record of int64 columns per turn,
+// in the same order as the CSV columns.
+type BinaryTurnRecorder struct {
+	writer  io.Writer
+	genders []PeepGender
+	header  bool // header was written
+}
+
+// NewBinaryTurnRecorder returns a recorder writing to writer, with one column per gender
+func NewBinaryTurnRecorder(writer io.Writer, genders []PeepGender) *BinaryTurnRecorder {
+	return &BinaryTurnRecorder{writer: writer, genders: genders}
+}
+
+// RecordTurn writes one record
+func (r *BinaryTurnRecorder) RecordTurn(s TurnStats) error {
+	if !r.header {
+		header := []byte(binaryTurnStatsMagic)
+		header = append(header, binaryTurnStatsVersion, byte(len(r.genders)))
+		for _, g := range r.genders {
+			header = append(header, byte(len(g)))
+			header = append(header, g...)
+		}
+		if _, err := r.writer.Write(header); err != nil {
+			return err
+		}
+		r.header = true
+	}
+	return binary.Write(r.writer, binary.LittleEndian, s.columns(r.genders))
+}
+
+// ReadTurnStats reads all turn stats written by a BinaryTurnRecorder
+func ReadTurnStats(reader io.Reader) ([]TurnStats, error) {
+	br := bufio.NewReader(reader)
+
+	header := make([]byte, len(binaryTurnStatsMagic)+2)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("reading header: %v", err)
+	}
+	if string(header[:len(binaryTurnStatsMagic)]) != binaryTurnStatsMagic {
+		return nil, errors.New("not a turn stats file")
+	}
+	if v := header[len(binaryTurnStatsMagic)]; v != binaryTurnStatsVersion {
+		return nil, fmt.Errorf("unsupported turn stats version %v", v)
+	}
+
+	var genders []PeepGender
+	for i := 0; i < int(header[len(header)-1]); i++ {
+		n, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("reading genders: %v", err)
+		}
+		name := make([]byte, n)
+		if _, err := io.ReadFull(br, name); err != nil {
+			return nil, fmt.Errorf("reading genders: %v", err)
+		}
+		genders = append(genders, PeepGender(name))
+	}
+
+	var all []TurnStats
+	record := make([]int64, len(turnStatsColumns)+len(genders))
+	for {
+		if err := binary.Read(br, binary.LittleEndian, record); err != nil {
+			if err == io.EOF {
+				return all, nil
+			}
+			return nil, fmt.Errorf("reading turn %v: %v", len(all)+1, err)
+		}
+		s := TurnStats{
+			Turn:    Turn(record[0]),
+			Alive:   record[1],
+			Births:  record[2],
+			Deaths:  record[3],
+			MinAge:  PeepAge(record[4]),
+			AvgAge:  PeepAge(record[5]),
+			MaxAge:  PeepAge(record[6]),
+			Genders: make(map[PeepGender]int64),
+		}
+		for i, g := range genders {
+			s.Genders[g] = record[len(turnStatsColumns)+i]
+		}
+		all = append(all, s)
+	}
+}
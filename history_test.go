@@ -0,0 +1,86 @@
+package world
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// turnRecorder keeps all turn stats in memory
+type turnRecorder []TurnStats
+
+func (r *turnRecorder) RecordTurn(s TurnStats) error {
+	*r = append(*r, s)
+	return nil
+}
+
+func TestTurnStats(t *testing.T) {
+	w := genWorld()
+	w.settings.NewPeep = 0
+	w.NewPeep("red", Location{1, 1, 0})
+	w.NewPeep("blue", Location{5, 5, 0})
+	old, _ := w.NewPeep("blue", Location{8, 8, 0})
+	old.age = w.settings.MaxAge
+
+	var recorded turnRecorder
+	w.AddTurnRecorder(&recorded)
+	w.NextTurn()
+
+	Convey("Each turn is recorded.", t, func() {
+		So(recorded, ShouldHaveLength, 1)
+		So(recorded[0], ShouldResemble, TurnStats{
+			Turn:    1,
+			Alive:   2,
+			Deaths:  1,
+			MinAge:  1,
+			AvgAge:  1,
+			MaxAge:  1,
+			Genders: map[PeepGender]int64{"red": 1, "blue": 1},
+		})
+	})
+}
+
+func TestTurnRecorders(t *testing.T) {
+	genders := []PeepGender{"red", "blue"}
+	turns := []TurnStats{
+		{Turn: 1, Alive: 3, Births: 3, MinAge: 0, AvgAge: 0, MaxAge: 0, Genders: map[PeepGender]int64{"red": 2, "blue": 1}},
+		{Turn: 2, Alive: 2, Deaths: 1, MinAge: 1, AvgAge: 1, MaxAge: 1, Genders: map[PeepGender]int64{"red": 2, "blue": 0}},
+	}
+
+	Convey("CSV has a header and one row per turn.", t, func() {
+		var out bytes.Buffer
+		r := NewCSVTurnRecorder(&out, genders)
+		for _, s := range turns {
+			So(r.RecordTurn(s), ShouldBeNil)
+		}
+		So(strings.Split(out.String(), "\n"), ShouldResemble, []string{
+			"turn,alive,births,deaths,min_age,avg_age,max_age,red,blue",
+			"1,3,3,0,0,0,0,2,1",
+			"2,2,0,1,1,1,1,2,0",
+			"",
+		})
+	})
+
+	Convey("Binary stats read back the same.", t, func() {
+		var out bytes.Buffer
+		r := NewBinaryTurnRecorder(&out, genders)
+		for _, s := range turns {
+			So(r.RecordTurn(s), ShouldBeNil)
+		}
+		read, err := ReadTurnStats(&out)
+		So(err, ShouldBeNil)
+		So(read, ShouldResemble, turns)
+	})
+
+	Convey("Truncated binary stats fail to read.", t, func() {
+		var out bytes.Buffer
+		NewBinaryTurnRecorder(&out, genders).RecordTurn(turns[0])
+		_, err := ReadTurnStats(bytes.NewReader(out.Bytes()[:out.Len()-3]))
+		So(err, ShouldNotBeNil)
+
+		_, err = ReadTurnStats(strings.NewReader("not stats"))
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -1,6 +1,46 @@
 package world
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Router returns the handler for all world pages and the JSON api
+func (w *World) Router() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/", w.HomeHandler)
+	r.HandleFunc("/metrics", w.MetricsHandler)
+	r.HandleFunc("/live", w.LiveHandler)
+	r.Handle("/live/ws", w.live)
+
+	api := r.PathPrefix("/api").Methods(http.MethodGet).Subrouter()
+	api.HandleFunc("/world", w.WorldHandler)
+	api.HandleFunc("/settings", w.SettingsHandler)
+	api.HandleFunc("/peeps", w.PeepsHandler)
+	api.HandleFunc("/peeps/{id}", w.PeepHandler)
+	api.HandleFunc("/peeps/{id}/ancestors", w.AncestorsHandler)
+	api.HandleFunc("/peeps/{id}/descendants", w.DescendantsHandler)
+	api.HandleFunc("/peeps/{id}/decisions", w.DecisionsHandler)
+	api.HandleFunc("/family", w.FamilyHandler)
+	api.HandleFunc("/food", w.FoodHandler)
+	api.HandleFunc("/control", w.ControlHandler)
+
+	control := r.PathPrefix("/api/control").Methods(http.MethodPost).Subrouter()
+	control.HandleFunc("/pause", w.PauseHandler)
+	control.HandleFunc("/resume", w.ResumeHandler)
+	control.HandleFunc("/step", w.StepHandler)
+	control.HandleFunc("/speed", w.SpeedHandler)
+	control.HandleFunc("/stop", w.StopHandler)
+	return r
+}
+
+// runWebServer serves until the server is shut down
+func (w *World) runWebServer(server *http.Server) {
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		Log(err)
+	}
+}
 
 func (w *World) HomeHandler(writer http.ResponseWriter, r *http.Request) {
 	var (
@@ -13,9 +53,10 @@ func (w *World) HomeHandler(writer http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	w.Show(writer)
-	w.ShowSettings(writer)
-	w.ShowSpawnPoints(writer)
-	w.ShowGrid(writer)
+	v := w.View()
+	v.Show(writer)
+	v.ShowSettings(writer)
+	v.ShowSpawnPoints(writer)
+	v.ShowGrid(writer)
 
 }
@@ -0,0 +1,293 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Lineage is the family record of a peep, kept after the peep is gone from the grid
+type Lineage struct {
+	ID         string     `json:"id"`
+	Gender     PeepGender `json:"gender"`
+	Parents    []string   `json:"parents,omitempty"`  // empty for peeps that did not spawn from others
+	Children   []string   `json:"children,omitempty"` // in order of birth
+	Generation int        `json:"generation"`         // 0 for peeps without parents, else one more than the oldest parent generation
+	BirthTurn  Turn       `json:"birth_turn"`
+	DeathTurn  Turn       `json:"death_turn,omitempty"`
+	Cause      DeathCause `json:"cause,omitempty"`
+}
+
+// copy returns a copy of l that shares no slices with it
+func (l *Lineage) copy() Lineage {
+	c := *l
+	c.Parents = append([]string(nil), l.Parents...)
+	c.Children = append([]string(nil), l.Children...)
+	return c
+}
+
+// recordBirth adds the lineage of a new peep and links it to its parents
+func (w *World) recordBirth(p *Peep, parents []Exister) {
+	l := &Lineage{
+		ID:        p.ID(),
+		Gender:    p.Gender(),
+		BirthTurn: w.turn - Turn(p.Age()),
+	}
+	for _, parent := range parents {
+		l.Parents = append(l.Parents, parent.ID())
+		if pl, ok := w.family.get(parent.ID()); ok {
+			pl.Children = append(pl.Children, p.ID())
+			if pl.Generation >= l.Generation {
+				l.Generation = pl.Generation + 1
+			}
+		}
+	}
+	w.family.add(l)
+}
+
+// recordDeath records the death of a peep in its lineage, which never changes again
+func (w *World) recordDeath(p *Peep) {
+	if l, ok := w.family.living[p.ID()]; ok {
+		l.DeathTurn = p.DeadAtTurn()
+		l.Cause = p.CauseOfDeath()
+		w.family.settle(l)
+	}
+}
+
+// familyTree holds the lineage of peeps by id.
+// Lineages of dead peeps never change again: they are settled, and shared by the world and all its views.
+type familyTree struct {
+	living  map[string]*Lineage // changed by the world, every view gets its own copy
+	settled *sync.Map           // id to *Lineage, only ever added to
+	view    bool                // true for the tree of a View, as it was at turn
+	turn    Turn
+}
+
+// newFamilyTree returns an empty tree
+func newFamilyTree() familyTree {
+	return familyTree{living: make(map[string]*Lineage), settled: &sync.Map{}}
+}
+
+// add adds a lineage to the tree, settled if the peep is dead
+func (t familyTree) add(l *Lineage) {
+	if l.DeathTurn > 0 {
+		t.settle(l)
+		return
+	}
+	t.living[l.ID] = l
+}
+
+// settle moves the lineage of a dead peep out of the living ones, it must not be changed anymore
+func (t familyTree) settle(l *Lineage) {
+	delete(t.living, l.ID)
+	t.settled.Store(l.ID, l)
+}
+
+// shows returns true if the settled lineage l belongs in the tree.
+// Views leave out peeps that died after their turn, they have a copy from when the peep was alive, or none.
+func (t familyTree) shows(l *Lineage) bool {
+	return !t.view || l.DeathTurn <= t.turn
+}
+
+// get returns the lineage of the peep with id
+func (t familyTree) get(id string) (*Lineage, bool) {
+	if l, ok := t.living[id]; ok {
+		return l, true
+	}
+	if v, ok := t.settled.Load(id); ok && t.shows(v.(*Lineage)) {
+		return v.(*Lineage), true
+	}
+	return nil, false
+}
+
+// each calls f with every lineage in the tree, in no particular order
+func (t familyTree) each(f func(l *Lineage)) {
+	for _, l := range t.living {
+		f(l)
+	}
+	t.settled.Range(func(_, v interface{}) bool {
+		if l := v.(*Lineage); t.shows(l) {
+			if _, ok := t.living[l.ID]; !ok {
+				f(l)
+			}
+		}
+		return true
+	})
+}
+
+// viewAt returns the tree as it is at turn, to be read by other goroutines.
+// Only the lineages of living peeps are copied, settled ones are shared.
+func (t familyTree) viewAt(turn Turn) familyTree {
+	c := familyTree{living: make(map[string]*Lineage, len(t.living)), settled: t.settled, view: true, turn: turn}
+	for id, l := range t.living {
+		lc := l.copy()
+		c.living[id] = &lc
+	}
+	return c
+}
+
+// lineage returns the family record of the peep with id
+func (t familyTree) lineage(id string) (Lineage, error) {
+	l, ok := t.get(id)
+	if !ok {
+		return Lineage{}, fmt.Errorf("no such peep: %v", id)
+	}
+	return l.copy(), nil
+}
+
+// relatives walks the family tree from id following next, each relative is returned once
+func (t familyTree) relatives(id string, next func(l *Lineage) []string) ([]Lineage, error) {
+	l, ok := t.get(id)
+	if !ok {
+		return nil, fmt.Errorf("no such peep: %v", id)
+	}
+
+	all := []Lineage{}
+	seen := map[string]bool{id: true}
+	queue := next(l)
+	for len(queue) > 0 {
+		rid := queue[0]
+		queue = queue[1:]
+		if seen[rid] {
+			continue
+		}
+		seen[rid] = true
+		if r, ok := t.get(rid); ok {
+			all = append(all, r.copy())
+			queue = append(queue, next(r)...)
+		}
+	}
+	return all, nil
+}
+
+// ancestors returns the parents, grandparents and so on of the peep with id, youngest generation first
+func (t familyTree) ancestors(id string) ([]Lineage, error) {
+	return t.relatives(id, func(l *Lineage) []string { return l.Parents })
+}
+
+// descendants returns the children, grandchildren and so on of the peep with id, oldest generation first
+func (t familyTree) descendants(id string) ([]Lineage, error) {
+	return t.relatives(id, func(l *Lineage) []string { return l.Children })
+}
+
+// sorted returns copies of all lineages, ordered by birth
+func (t familyTree) sorted() []Lineage {
+	all := []Lineage{}
+	t.each(func(l *Lineage) {
+		all = append(all, l.copy())
+	})
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].BirthTurn != all[j].BirthTurn {
+			return all[i].BirthTurn < all[j].BirthTurn
+		}
+		return all[i].ID < all[j].ID
+	})
+	return all
+}
+
+// writeJSON writes the tree as a JSON list, ordered by birth
+func (t familyTree) writeJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(t.sorted())
+}
+
+// writeDOT writes the tree as a Graphviz graph called name, with an edge from each parent to its children.
+// Peeps are colored by gender, dead peeps are dashed.
+func (t familyTree) writeDOT(writer io.Writer, name string) error {
+	tree := t.sorted()
+
+	if _, err := fmt.Fprintf(writer, "digraph %q {\n", name); err != nil {
+		return err
+	}
+	for _, l := range tree {
+		style := "solid"
+		if l.DeathTurn > 0 {
+			style = "dashed"
+		}
+		short := l.ID
+		if len(short) > 8 {
+			short = short[:8]
+		}
+		fmt.Fprintf(writer, "\t%q [label=\"%v\\ngen %v, born %v\", color=%q, style=%v];\n",
+			l.ID, short, l.Generation, l.BirthTurn, l.Gender, style)
+	}
+	for _, l := range tree {
+		for _, c := range l.Children {
+			fmt.Fprintf(writer, "\t%q -> %q;\n", l.ID, c)
+		}
+	}
+	_, err := fmt.Fprintf(writer, "}\n")
+	return err
+}
+
+// Lineage returns the family record of the peep with id
+func (w *World) Lineage(id string) (Lineage, error) {
+	return w.family.lineage(id)
+}
+
+// Ancestors returns the parents, grandparents and so on of the peep with id, youngest generation first
+func (w *World) Ancestors(id string) ([]Lineage, error) {
+	return w.family.ancestors(id)
+}
+
+// Descendants returns the children, grandchildren and so on of the peep with id, oldest generation first
+func (w *World) Descendants(id string) ([]Lineage, error) {
+	return w.family.descendants(id)
+}
+
+// FamilyTree returns the lineage of every peep ever born in this world, ordered by birth
+func (w *World) FamilyTree() []Lineage {
+	return w.family.sorted()
+}
+
+// WriteFamilyTreeJSON writes the family tree as a JSON list, see FamilyTree
+func (w *World) WriteFamilyTreeJSON(writer io.Writer) error {
+	return w.family.writeJSON(writer)
+}
+
+// WriteFamilyTreeDOT writes the family tree as a Graphviz graph, with an edge from each parent to its children.
+// Peeps are colored by gender, dead peeps are dashed.
+func (w *World) WriteFamilyTreeDOT(writer io.Writer) error {
+	return w.family.writeDOT(writer, w.name)
+}
+
+// FamilyHandler serves the family tree, as JSON or as Graphviz DOT with format=dot
+func (w *World) FamilyHandler(writer http.ResponseWriter, r *http.Request) {
+	v := w.View()
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		writeJSON(writer, v.family.sorted())
+	case "dot":
+		writer.Header().Set("Content-Type", "text/vnd.graphviz")
+		v.family.writeDOT(writer, v.Info.Name)
+	default:
+		writeError(writer, http.StatusBadRequest, fmt.Errorf("unknown format %q, use json or dot", r.URL.Query().Get("format")))
+	}
+}
+
+// AncestorsHandler serves the ancestors of a peep
+func (w *World) AncestorsHandler(writer http.ResponseWriter, r *http.Request) {
+	relatives, err := w.View().family.ancestors(mux.Vars(r)["id"])
+	serveRelatives(writer, relatives, err)
+}
+
+// DescendantsHandler serves the descendants of a peep
+func (w *World) DescendantsHandler(writer http.ResponseWriter, r *http.Request) {
+	relatives, err := w.View().family.descendants(mux.Vars(r)["id"])
+	serveRelatives(writer, relatives, err)
+}
+
+// serveRelatives serves the result of Ancestors or Descendants
+func serveRelatives(writer http.ResponseWriter, relatives []Lineage, err error) {
+	if err != nil {
+		writeError(writer, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(writer, relatives)
+}
@@ -0,0 +1,145 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// spawnChild spawns a child of left and right and returns it
+func spawnChild(w *World, left, right *Peep) *Peep {
+	before := len(left.Children())
+	left.age, right.age = w.settings.SpawnAge, w.settings.SpawnAge
+	if err := w.SameGenderSpawn(left, right); err != nil || len(left.Children()) == before {
+		return nil
+	}
+	w.Publish()
+	child, _ := w.View().Peep(left.Children()[before])
+	return w.LocationExister(child.Location).(*Peep)
+}
+
+func TestLineage(t *testing.T) {
+	w := genWorld()
+	w.settings.SpawnProbability = 1
+	w.settings.PeepSpawnInterval = 0
+
+	a, _ := w.NewPeep("red", Location{1, 1, 0})
+	b, _ := w.NewPeep("red", Location{2, 1, 0})
+	c := spawnChild(w, a, b)
+	d, _ := w.NewPeep("red", Location{-5, -5, 0})
+	d.age = w.settings.SpawnAge
+	w.UpdateGrid(d, d.Location(), Location{c.Location().X, c.Location().Y + 1, 0})
+	e := spawnChild(w, c, d)
+
+	Convey("Births record parents and generation.", t, func() {
+		So(c, ShouldNotBeNil)
+		So(e, ShouldNotBeNil)
+		So(a.Generation(), ShouldEqual, 0)
+		So(c.Parents(), ShouldResemble, []string{a.ID(), b.ID()})
+		So(c.Generation(), ShouldEqual, 1)
+		So(e.Generation(), ShouldEqual, 2)
+		So(a.Children(), ShouldResemble, []string{c.ID()})
+	})
+
+	Convey("Birth events carry the parents.", t, func() {
+		births := (&eventRecorder{events: w.TurnEvents()}).ofType(EventBirth)
+		So(births[len(births)-1].Parents, ShouldResemble, []string{c.ID(), d.ID()})
+	})
+
+	Convey("Ancestors and descendants are found.", t, func() {
+		ancestors, err := w.Ancestors(e.ID())
+		So(err, ShouldBeNil)
+		So(lineageIDs(ancestors), ShouldResemble, []string{c.ID(), d.ID(), a.ID(), b.ID()})
+
+		descendants, err := w.Descendants(a.ID())
+		So(err, ShouldBeNil)
+		So(lineageIDs(descendants), ShouldResemble, []string{c.ID(), e.ID()})
+
+		_, err = w.Ancestors("nobody")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Lineage survives death.", t, func() {
+		w.kill(a, DeathRandom)
+		l, err := w.Lineage(a.ID())
+		So(err, ShouldBeNil)
+		So(l.Cause, ShouldEqual, DeathRandom)
+		So(l.Children, ShouldResemble, []string{c.ID()})
+	})
+
+	Convey("The family tree is exported as DOT.", t, func() {
+		var out bytes.Buffer
+		So(w.WriteFamilyTreeDOT(&out), ShouldBeNil)
+		So(out.String(), ShouldStartWith, "digraph \"Alpha1\" {\n")
+		So(out.String(), ShouldContainSubstring, "\t\""+a.ID()+"\" -> \""+c.ID()+"\";\n")
+		So(out.String(), ShouldContainSubstring, "\t\""+c.ID()+"\" -> \""+e.ID()+"\";\n")
+		So(strings.Count(out.String(), "->"), ShouldEqual, 4)
+	})
+
+	Convey("The family tree is exported as JSON.", t, func() {
+		var out bytes.Buffer
+		So(w.WriteFamilyTreeJSON(&out), ShouldBeNil)
+		var tree []Lineage
+		So(json.Unmarshal(out.Bytes(), &tree), ShouldBeNil)
+		So(tree, ShouldResemble, w.FamilyTree())
+		So(tree, ShouldHaveLength, 5)
+	})
+
+	Convey("Relatives are served over http.", t, func() {
+		w.Publish()
+		var ancestors []Lineage
+		So(get(w, "/api/peeps/"+c.ID()+"/ancestors", &ancestors), ShouldEqual, http.StatusOK)
+		So(lineageIDs(ancestors), ShouldResemble, []string{a.ID(), b.ID()})
+
+		var detail PeepDetail
+		So(get(w, "/api/peeps/"+c.ID(), &detail), ShouldEqual, http.StatusOK)
+		So(detail.Generation, ShouldEqual, 1)
+		So(detail.Children, ShouldResemble, []string{e.ID()})
+
+		So(get(w, "/api/peeps/nobody/descendants", nil), ShouldEqual, http.StatusNotFound)
+		So(get(w, "/api/family?format=png", nil), ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("Views share the lineage of dead peeps and copy that of the living.", t, func() {
+		w.turn = 5
+		w.Publish()
+		before := w.View()
+		w.turn = 6
+		w.kill(b, DeathRandom)
+		w.Publish()
+		after := w.View()
+
+		l, _ := before.family.lineage(b.ID())
+		So(l.DeathTurn, ShouldEqual, 0)
+		l, _ = after.family.lineage(b.ID())
+		So(l.DeathTurn, ShouldEqual, 6)
+		So(before.family.sorted(), ShouldHaveLength, 5)
+
+		dead, _ := before.family.get(a.ID())
+		again, _ := after.family.get(a.ID())
+		So(dead, ShouldPointTo, again)
+		living, _ := before.family.get(c.ID())
+		now, _ := w.family.get(c.ID())
+		So(living, ShouldNotPointTo, now)
+	})
+
+	Convey("Lineage is saved in snapshots.", t, func() {
+		var saved bytes.Buffer
+		So(w.Snapshot(&saved), ShouldBeNil)
+		restored, err := LoadWorld(&saved, false)
+		So(err, ShouldBeNil)
+		So(restored.FamilyTree(), ShouldResemble, w.FamilyTree())
+	})
+}
+
+func lineageIDs(all []Lineage) []string {
+	var ids []string
+	for _, l := range all {
+		ids = append(ids, l.ID)
+	}
+	return ids
+}
@@ -0,0 +1,197 @@
+package world
+
+import (
+	_ "embed" // for the live page
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+//go:embed web/live.html
+var livePage []byte // draws the world on a canvas, fed by LiveRenderer
+
+// liveClientBuffer is how many messages may queue up for a browser before it is dropped
+const liveClientBuffer = 16
+
+// liveMessage is sent to browsers.
+// A "reset" message carries the whole world, "diff" messages only what changed since the previous one.
+type liveMessage struct {
+	Type      string                  `json:"type"`
+	Turn      Turn                    `json:"turn"`
+	Size      *Size                   `json:"size,omitempty"`      // reset only
+	Topology  Topology                `json:"topology,omitempty"`  // reset only, empty when bounded
+	Homebases map[PeepGender]Location `json:"homebases,omitempty"` // reset, or when they changed
+	Terrain   []TerrainCell           `json:"terrain,omitempty"`   // reset, or when it changed; all of it
+	Set       []Cell                  `json:"set,omitempty"`       // cells that are new or changed
+	Clear     []Location              `json:"clear,omitempty"`     // cells that are now empty
+}
+
+// LiveRenderer streams the world to browsers over WebSocket, one diff per turn.
+// Draw is called by the world, ServeHTTP serves the WebSocket.
+type LiveRenderer struct {
+	lock     sync.Mutex
+	frame    *Frame                    // last frame drawn
+	clients  map[chan liveMessage]bool // one queue per browser
+	upgrader websocket.Upgrader
+}
+
+// NewLiveRenderer returns a renderer with no browsers attached
+func NewLiveRenderer() *LiveRenderer {
+	return &LiveRenderer{
+		clients: make(map[chan liveMessage]bool),
+	}
+}
+
+// resetMessage returns a message describing the whole frame
+func resetMessage(f *Frame) liveMessage {
+	size := f.Size
+	return liveMessage{
+		Type:      "reset",
+		Turn:      f.Turn,
+		Size:      &size,
+		Topology:  f.Topology,
+		Homebases: f.Homebases,
+		Terrain:   f.Terrain,
+		Set:       f.Cells,
+	}
+}
+
+// diffMessage returns a message describing what changed between two frames
+func diffMessage(prev, next *Frame) liveMessage {
+	m := liveMessage{Type: "diff", Turn: next.Turn}
+
+	before := make(map[Location]Cell, len(prev.Cells))
+	for _, c := range prev.Cells {
+		before[c.Location] = c
+	}
+	for _, c := range next.Cells {
+		if old, ok := before[c.Location]; !ok || old != c {
+			m.Set = append(m.Set, c)
+		}
+		delete(before, c.Location)
+	}
+	for loc := range before {
+		m.Clear = append(m.Clear, loc)
+	}
+	SortLocations(m.Clear)
+
+	if len(prev.Homebases) != len(next.Homebases) {
+		m.Homebases = next.Homebases
+	}
+	for gender, loc := range next.Homebases {
+		if prev.Homebases[gender] != loc {
+			m.Homebases = next.Homebases
+		}
+	}
+	if !sameTerrain(prev.Terrain, next.Terrain) {
+		m.Terrain = next.Terrain
+		if m.Terrain == nil {
+			m.Terrain = []TerrainCell{} // sent, so browsers clear the old terrain
+		}
+	}
+	return m
+}
+
+// sameTerrain returns true if a and b describe the same terrain
+func sameTerrain(a, b []TerrainCell) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Draw sends what changed since the last frame to all browsers
+func (l *LiveRenderer) Draw(f *Frame) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if len(l.clients) > 0 {
+		m := resetMessage(f)
+		if l.frame != nil {
+			m = diffMessage(l.frame, f)
+		}
+		for client := range l.clients {
+			select {
+			case client <- m:
+			default:
+				// too slow to keep up, it has to reconnect
+				l.remove(client)
+			}
+		}
+	}
+	l.frame = f
+}
+
+// add registers a new browser and queues the whole world for it
+func (l *LiveRenderer) add() chan liveMessage {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	client := make(chan liveMessage, liveClientBuffer)
+	if l.frame != nil {
+		client <- resetMessage(l.frame)
+	}
+	l.clients[client] = true
+	return client
+}
+
+// remove unregisters a browser, the lock must be held
+func (l *LiveRenderer) remove(client chan liveMessage) {
+	if l.clients[client] {
+		delete(l.clients, client)
+		close(client)
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket and streams the world until the browser goes away
+func (l *LiveRenderer) ServeHTTP(writer http.ResponseWriter, r *http.Request) {
+	conn, err := l.upgrader.Upgrade(writer, r, nil)
+	if err != nil {
+		return // the upgrader already replied
+	}
+	defer conn.Close()
+
+	client := l.add()
+	defer func() {
+		l.lock.Lock()
+		l.remove(client)
+		l.lock.Unlock()
+	}()
+
+	// Browsers don't send anything, reading only notices when they go away
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case m, ok := <-client:
+			if !ok {
+				return
+			}
+			if err := conn.WriteJSON(m); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+// LiveHandler serves the page showing the world live
+func (w *World) LiveHandler(writer http.ResponseWriter, r *http.Request) {
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Write(livePage)
+}
@@ -0,0 +1,93 @@
+package world
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiffMessage(t *testing.T) {
+	prev := &Frame{
+		Turn:      1,
+		Homebases: map[PeepGender]Location{"red": {9, 9, 0}},
+		Cells: []Cell{
+			{Location: Location{1, 1, 0}, ID: "a", Gender: "red", Age: 1},
+			{Location: Location{2, 2, 0}, ID: "b", Gender: "red", Age: 1},
+			{Location: Location{3, 3, 0}, ID: "c", Gender: "red", Age: 1},
+		},
+	}
+	next := &Frame{
+		Turn:      2,
+		Homebases: map[PeepGender]Location{"red": {9, 9, 0}},
+		Cells: []Cell{
+			{Location: Location{1, 1, 0}, ID: "a", Gender: "red", Age: 1},
+			{Location: Location{2, 3, 0}, ID: "b", Gender: "red", Age: 2},
+			{Location: Location{3, 3, 0}, ID: "c", Gender: "red", Age: 1, Dead: true},
+		},
+	}
+
+	Convey("Only changed cells are sent.", t, func() {
+		m := diffMessage(prev, next)
+		So(m.Type, ShouldEqual, "diff")
+		So(m.Turn, ShouldEqual, 2)
+		So(m.Set, ShouldResemble, []Cell{next.Cells[1], next.Cells[2]})
+		So(m.Clear, ShouldResemble, []Location{{2, 2, 0}})
+		So(m.Homebases, ShouldBeNil)
+	})
+
+	Convey("Homebases are sent when they change.", t, func() {
+		next.Homebases = map[PeepGender]Location{"red": {8, 8, 0}}
+		So(diffMessage(prev, next).Homebases, ShouldResemble, next.Homebases)
+	})
+
+	Convey("Terrain is sent when it changes.", t, func() {
+		So(diffMessage(prev, next).Terrain, ShouldBeNil)
+		next.Terrain = []TerrainCell{{Location{0, 0, 0}, TerrainWall}}
+		So(diffMessage(prev, next).Terrain, ShouldResemble, next.Terrain)
+		So(diffMessage(next, prev).Terrain, ShouldResemble, []TerrainCell{})
+	})
+}
+
+func TestLive(t *testing.T) {
+	w := genWorld()
+	w.SetHomebase("red", Location{9, 9, 0})
+	server := httptest.NewServer(w.Router())
+	defer server.Close()
+
+	Convey("The live page is served.", t, func() {
+		recorder := httptest.NewRecorder()
+		w.Router().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/live", nil))
+		So(recorder.Code, ShouldEqual, http.StatusOK)
+		So(recorder.Body.String(), ShouldContainSubstring, "/live/ws")
+	})
+
+	w.NewPeep("red", Location{1, 1, 0})
+	w.NextTurn()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/live/ws", nil)
+	Convey("Browsers can connect.", t, func() {
+		So(err, ShouldBeNil)
+	})
+	defer conn.Close()
+
+	Convey("Browsers get the whole world first, then what changed each turn.", t, func() {
+		var m liveMessage
+		So(conn.ReadJSON(&m), ShouldBeNil)
+		So(m.Type, ShouldEqual, "reset")
+		So(m.Turn, ShouldEqual, 1)
+		So(m.Size.MaxX, ShouldEqual, 10)
+		So(m.Homebases["red"], ShouldResemble, Location{9, 9, 0})
+		So(len(m.Set), ShouldEqual, 1)
+
+		w.NewPeep("blue", Location{5, 5, 0})
+		w.NextTurn()
+		So(conn.ReadJSON(&m), ShouldBeNil)
+		So(m.Type, ShouldEqual, "diff")
+		So(m.Turn, ShouldEqual, 2)
+		So(len(m.Set), ShouldEqual, 2) // the new peep, and the old one got older
+	})
+}
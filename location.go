@@ -2,26 +2,15 @@ package world
 
 import (
 	"fmt"
-	"math"
-	"math/rand"
+	"sort"
 	"unicode"
-
-	termbox "github.com/nsf/termbox-go"
 )
 
-// Grid holds information about what object is in what cell in the world.
-type Grid struct {
-	size *Size
-
-	// Each object is represented by a unique string
-	objects *dmap
-}
-
 // Location specifies one coordinate in the world.
 type Location struct {
-	X int32
-	Y int32
-	Z int32
+	X int32 `json:"x" yaml:"x"`
+	Y int32 `json:"y" yaml:"y"`
+	Z int32 `json:"z" yaml:"z"`
 }
 
 func (l Location) String() string {
@@ -38,6 +27,24 @@ func (l Location) SameAs(other Location) bool {
 
 }
 
+// Less returns true if l sorts before other (by Z, then Y, then X)
+func (l Location) Less(other Location) bool {
+	if l.Z != other.Z {
+		return l.Z < other.Z
+	}
+	if l.Y != other.Y {
+		return l.Y < other.Y
+	}
+	return l.X < other.X
+}
+
+// SortLocations sorts a list of locations in place, see Location.Less
+func SortLocations(locations []Location) {
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].Less(locations[j])
+	})
+}
+
 // NewLocation returns a new location at origin
 func NewLocation() Location {
 	return Location{0, 0, 0}
@@ -51,34 +58,13 @@ func NewLocationXYZ(x, y, z int32) Location {
 // Size specifies the world size
 // termbox starts at (0, 0) upper-left.
 type Size struct {
-	MaxX int32
-	MaxY int32
-	MaxZ int32
-
-	MinX int32
-	MinY int32
-	MinZ int32
-}
-
-// Exister defines an object that exists in the grid
-type Exister interface {
-	ID() string
-	Gender() PeepGender
-	Age() PeepAge
-	IsAlive() bool
-	Homebase() Location
-	DeadAtTurn() Turn
-	Met() map[Exister]Turn // Map of exister to turn when met
-	Meet(Exister, Turn)
-	MetPeep(Exister) bool                    // Whether the two have met
-	SetLookTurn(Turn)                        // sets the turn the exister looked around
-	LookTurn() Turn                          // last time exister looked around
-	World() *World                           // returns pointer to the World this exister inhabits
-	SetNeighbors()                           // sets the neighbors around the exister on this turn
-	NeighborsFromLook() map[Location]Exister // gets the neighbors of the exister (from the last look time)
-	Location() Location                      // location of the exister on the map
-	SpawnTurn() Turn                         // last time exister spawned
-	SetSpawnTurn(Turn)                       // sets the spawn turn
+	MaxX int32 `json:"max_x" yaml:"max_x"`
+	MaxY int32 `json:"max_y" yaml:"max_y"`
+	MaxZ int32 `json:"max_z" yaml:"max_z"`
+
+	MinX int32 `json:"min_x" yaml:"min_x"`
+	MinY int32 `json:"min_y" yaml:"min_y"`
+	MinZ int32 `json:"min_z" yaml:"min_z"`
 }
 
 // MaxX returns the max X value of the grid that can be occupied
@@ -113,21 +99,39 @@ func (w *World) MinZ() int32 {
 
 // SpawnLocations returns all locations available for spawning
 func (w *World) SpawnLocations() []Location {
+	return spawnLocations(w.settings)
+}
+
+// spawnLocations returns the corners inside the border of the grid.
+// Across edges that join (see Topology) corners touch, so there they are a quarter of the way in from each side instead.
+func spawnLocations(s Settings) []Location {
+	minX, maxX, minY, maxY := s.Size.MinX+1, s.Size.MaxX-1, s.Size.MinY+1, s.Size.MaxY-1
+	if s.wrapsX() {
+		width := maxX - minX + 1
+		minX, maxX = minX+width/4, minX+width*3/4
+	}
+	if s.wrapsY() {
+		height := maxY - minY + 1
+		minY, maxY = minY+height/4, minY+height*3/4
+	}
+
 	l := []Location{}
 	// top left
-	l = append(l, Location{w.MinX(), w.MaxY(), 0})
+	l = append(l, Location{minX, maxY, 0})
 	// top right
-	l = append(l, Location{w.MaxX(), w.MaxY(), 0})
+	l = append(l, Location{maxX, maxY, 0})
 
 	// bottom left
-	l = append(l, Location{w.MinX(), w.MinY(), 0})
+	l = append(l, Location{minX, minY, 0})
 	// bottom right
-	l = append(l, Location{w.MaxX(), w.MinY(), 0})
+	l = append(l, Location{maxX, minY, 0})
 
 	return l
 }
 
 // LocationNeighbors returns all neighboring locations to the given one within the viewDistance
+// Locations outside the grid or on impassable terrain are left out. Across edges that join, see Topology,
+// neighbors are on the other side of the grid, and each is returned once however small the grid.
 func (w *World) LocationNeighbors(l Location, viewDistance int32) []Location {
 	// check cache first
 	if neighbors, ok := w.locationNeighbors[neighborViewDistanceCache{l, viewDistance}]; ok {
@@ -135,14 +139,16 @@ func (w *World) LocationNeighbors(l Location, viewDistance int32) []Location {
 	}
 
 	neighbors := []Location{}
+	seen := map[Location]bool{l: true} // skip our own location
 
 	for x := -viewDistance; x <= viewDistance; x++ {
 		for y := -viewDistance; y <= viewDistance; y++ {
-			newLoc := NewLocationXYZ(l.X+x, l.Y+y, l.Z)
-			if newLoc.SameAs(l) {
-				continue // skip our own location
+			newLoc := w.step(l, x, y, 0)
+			if seen[newLoc] {
+				continue
 			}
-			if !w.IsOutsideGrid(newLoc.X, newLoc.Y, newLoc.Z) {
+			seen[newLoc] = true
+			if !w.IsBlocked(newLoc.X, newLoc.Y, newLoc.Z) {
 				neighbors = append(neighbors, newLoc)
 			}
 		}
@@ -160,6 +166,9 @@ func (w *World) allLocations() []Location {
 	var z int32 // World is flat for now.
 	for x := w.MinX(); x <= w.MaxX(); x++ {
 		for y := w.MinY(); y <= w.MaxY(); y++ {
+			if w.IsBlocked(x, y, z) {
+				continue
+			}
 			all = append(all, NewLocationXYZ(x, y, z))
 		}
 	}
@@ -172,7 +181,7 @@ func (w *World) FindAnyEmptyLocation() (Location, error) {
 	for x := w.MinX(); x <= w.MaxX(); x++ {
 		for y := w.MinY(); y <= w.MaxY(); y++ {
 			loc := NewLocationXYZ(x, y, z)
-			if !w.IsOccupiedLocation(loc) {
+			if !w.IsOccupiedLocation(loc) && !w.IsBlocked(x, y, z) {
 				return loc, nil
 			}
 		}
@@ -193,13 +202,23 @@ func (w *World) FindEmptyLocation(locations ...Location) (Location, error) {
 	return Location{}, fmt.Errorf("No available locations next to %v", locations)
 }
 
+// fertility returns the chances of two peeps that meet spawning a new one, see Genome.Fertility
+func fertility(left, right Exister) float64 {
+	return (genome(left).Fertility + genome(right).Fertility) / 2
+}
+
 // OfSpawnAge returns true of Exister is old enough to spawn
 func (w *World) OfSpawnAge(e Exister) bool {
-	return e.Age() >= w.settings.SpawnAge
+	return age(e) >= w.settings.SpawnAge
 }
 
 // SameGenderSpawn makes a new peep next to one of the provided peeps, if they are of the same gender
-func (w *World) SameGenderSpawn(left, right Exister) error {
+func (w *World) SameGenderSpawn(leftExister, rightExister Exister) error {
+	left, lok := leftExister.(mate)
+	right, rok := rightExister.(mate)
+	if !lok || !rok {
+		return fmt.Errorf("Only peeps spawn!")
+	}
 	if left.Gender() != right.Gender() {
 		return fmt.Errorf("Different genders don't spawn!")
 	}
@@ -226,11 +245,16 @@ func (w *World) SameGenderSpawn(left, right Exister) error {
 
 	newLocation, err := w.FindEmptyLocation(locLeft, locRight)
 	if err != nil {
-		return fmt.Errorf("Unable to find empty location next to spawners!")
+		err = fmt.Errorf("Unable to find empty location next to spawners!")
+		w.emitSpawnBlocked(left, right, err)
+		return err
 	}
 
-	if rand.Float64() < w.settings.SpawnProbability {
-		w.NewPeep(left.Gender(), newLocation)
+	if w.random.Float64() < fertility(left, right) {
+		if _, err := w.spawnPeep(left.Gender(), &newLocation, 0, []Exister{left, right}); err != nil {
+			w.emitSpawnBlocked(left, right, err)
+			return err
+		}
 		left.SetSpawnTurn(w.turn)
 		right.SetSpawnTurn(w.turn)
 	}
@@ -238,7 +262,12 @@ func (w *World) SameGenderSpawn(left, right Exister) error {
 }
 
 // DiffGenderSpawn makes a new peep next to one of the provided peeps, if they are of a different gender
-func (w *World) DiffGenderSpawn(left, right Exister) error {
+func (w *World) DiffGenderSpawn(leftExister, rightExister Exister) error {
+	left, lok := leftExister.(mate)
+	right, rok := rightExister.(mate)
+	if !lok || !rok {
+		return fmt.Errorf("Only peeps spawn!")
+	}
 	if left.Gender() != right.Gender() {
 		var locLeft, locRight Location
 		var err error
@@ -249,31 +278,44 @@ func (w *World) DiffGenderSpawn(left, right Exister) error {
 			return fmt.Errorf("Exister %v does not exist...", right)
 		}
 		newLocation, err := w.FindEmptyLocation(locLeft, locRight)
-		if err == nil {
-			if rand.Float64() < w.settings.SpawnProbability {
-				w.NewPeep("", newLocation)
-				left.SetSpawnTurn(w.turn)
-				right.SetSpawnTurn(w.turn)
+		if err != nil {
+			w.emitSpawnBlocked(left, right, err)
+			return nil
+		}
+		if w.random.Float64() < fertility(left, right) {
+			if _, err := w.spawnPeep("", &newLocation, 0, []Exister{left, right}); err != nil {
+				w.emitSpawnBlocked(left, right, err)
+				return nil
 			}
+			left.SetSpawnTurn(w.turn)
+			right.SetSpawnTurn(w.turn)
 		}
 	}
 	return nil
 }
 
 // Meet is called when two Existers bump into each other
+// Predators eat the peeps they meet, see hunt.
 func (w *World) Meet(left, right Exister) {
+	if w.hunt(left, right) {
+		return
+	}
+	l, lok := left.(Social)
+	r, rok := right.(Social)
+	if !lok || !rok {
+		return // bumping into a rock is no meeting
+	}
 
 	// If they are of the same gender, they spawn a new one (yes yes, I know it's backwards)
 	// Spawns only happen the first time peeps meet
-	if !left.MetPeep(right) && !right.MetPeep(left) { // no need to check both?
-		if err := w.SameGenderSpawn(left, right); err != nil {
-			//Log(left.Age(), right.Age())
-			//Log(err)
-		}
+	if !l.MetPeep(right) && !r.MetPeep(left) { // no need to check both?
+		// blocked spawns are recorded as events, other errors mean the peeps can't spawn
+		w.SameGenderSpawn(left, right)
 	}
 	// Record the meeting
-	left.Meet(right, w.turn)
-	right.Meet(left, w.turn)
+	l.Meet(right, w.turn)
+	r.Meet(left, w.turn)
+	w.emit(Event{Type: EventMeet, ID: left.ID(), Other: right.ID()})
 
 	// If they are of a different gender, they spawn a random child.
 	//w.DiffGenderSpawn(left, right)
@@ -282,19 +324,16 @@ func (w *World) Meet(left, right Exister) {
 
 // LocationExister return an exister at the location
 func (w *World) LocationExister(l Location) Exister {
-	return w.grid.objects.GetByLocation(l)
+	return w.grid.At(l)
 }
 
-// IsOccupiedLocation returns True if the given Location is occupied by something alive
+// IsOccupiedLocation returns True if the given Location is occupied by something alive, or that never lived
 func (w *World) IsOccupiedLocation(l Location) bool {
 	e := w.LocationExister(l)
 	if e == nil {
 		return false
 	}
-	if e.IsAlive() {
-		return true
-	}
-	return false
+	return isPresent(e)
 }
 
 // UpdateGrid updates a location on the world grid with the current occupant
@@ -303,12 +342,12 @@ func (w *World) IsOccupiedLocation(l Location) bool {
 func (w *World) UpdateGrid(e Exister, src Location, dst Location) error {
 	if src.SameAs(dst) {
 		// Set explicitly again to catch new peeps being created
-		w.grid.objects.Set(e, src)
+		w.grid.Set(e, src)
 		return nil
 	}
 	// Check if someone else is already squatting here
 	if w.IsOccupiedLocation(dst) {
-		squatter := w.grid.objects.GetByLocation(dst)
+		squatter := w.grid.At(dst)
 		if squatter != nil && squatter.ID() != e.ID() {
 			// We have a meeting, perhaps something happens here
 			w.Meet(e, squatter)
@@ -320,55 +359,40 @@ func (w *World) UpdateGrid(e Exister, src Location, dst Location) error {
 	if w.IsOutsideGrid(dst.X, dst.Y, dst.Z) {
 		return fmt.Errorf("Location %v is outside the grid", Location{dst.X, dst.Y, dst.Z})
 	}
+	if t := w.TerrainAt(dst); !t.Passable() {
+		return fmt.Errorf("Location %v is %v", dst, t)
+	}
 
-	w.grid.objects.DelByLocation(src)
-	w.grid.objects.Set(e, dst)
+	w.grid.Set(e, dst)
 
 	return nil
 }
 
 func (w *World) totalNeighbors(l Location, viewDistance int32) int32 {
-
 	// view of 0 means can't see at all
-	if viewDistance == 0 {
-		return 0
-	}
-
-	var neighbors int32
-
-	for x := -viewDistance; x <= viewDistance; x++ {
-		for y := -viewDistance; y <= viewDistance; y++ {
-			newLoc := NewLocationXYZ(l.X+x, l.Y+y, l.Z)
-			if newLoc.SameAs(l) {
-				continue // skip our own location
-			}
-			if !w.IsOutsideGrid(newLoc.X, newLoc.Y, newLoc.Z) {
-				neighbors++
-			}
-		}
-	}
-
-	return neighbors
+	return int32(len(w.LocationNeighbors(l, viewDistance)))
 }
 
-// SpawnPoint returns a spawn point for the given Exister
+// SpawnPoint returns a spawn point for the given Exister: its homebase, or where it is if it has none
 func (w *World) SpawnPoint(e Exister) Location {
-	return e.Homebase()
+	if s, ok := e.(Social); ok {
+		return s.Homebase()
+	}
+	return e.Location()
 }
 
 // CheckOutsideGrid return error if the move would place object outside grid.
+// Moves across edges that join come back on the other side, see Topology.
 func (w *World) CheckMovementOutsideGrid(src Location, x, y, z int32) error {
-	newX := src.X + x
-	newY := src.Y + y
-	newZ := src.Z + z
-	if w.IsOutsideGrid(newX, newY, newZ) {
-		return fmt.Errorf("Location %v is outside the grid!", Location{newX, newY, newZ})
+	dst := w.step(src, x, y, z)
+	if w.IsOutsideGrid(dst.X, dst.Y, dst.Z) {
+		return fmt.Errorf("Location %v is outside the grid!", dst)
 	}
 	return nil
 }
 
 // IsOutsideGrid returns true if coordinates are outside the grid
-// X and Y also remove 1 line for border
+// X and Y also remove 1 line for border. Coordinates are not wrapped, see World.wrap.
 func (w *World) IsOutsideGrid(x, y, z int32) bool {
 	if x > w.settings.Size.MaxX-1 || x < w.settings.Size.MinX+1 {
 		return true
@@ -385,11 +409,12 @@ func (w *World) IsOutsideGrid(x, y, z int32) bool {
 
 // ExisterLocation returns a location, given an exister.
 func (w *World) ExisterLocation(e Exister) (Location, error) {
-	return w.grid.objects.GetByExister(e)
+	return w.grid.LocationOf(e)
 }
 
 // Move moves a mover in direction and magnitude specified.
 // e.g. (1,0,0) will move X to the right 1 and nothing on y and z
+// Walls and water cannot be entered, rough ground costs more energy to step on.
 func (w *World) Move(e Exister, x, y, z int32) error {
 	var src, dst Location
 	var err error
@@ -400,14 +425,16 @@ func (w *World) Move(e Exister, x, y, z int32) error {
 		return err
 	}
 
-	dst = NewLocationXYZ(src.X+x, src.Y+y, src.Z+z)
-	if e.Homebase().SameAs(dst) {
+	dst = w.step(src, x, y, z)
+	if w.offLimits(e, dst) {
 		return fmt.Errorf("Cannot move on top of homebase!")
 	}
 
 	if err := w.UpdateGrid(e, src, dst); err != nil {
 		return err
 	}
+	w.spendEnergy(e, w.settings.MoveEnergy*float64(w.TerrainAt(dst).Cost()))
+	w.emit(Event{Type: EventMove, ID: e.ID(), From: &src, Location: &dst})
 	return nil
 }
 
@@ -415,143 +442,20 @@ func (w *World) Move(e Exister, x, y, z int32) error {
 func (w *World) ExisterIcon(e Exister) rune {
 	midAge := w.settings.SpawnAge
 
+	if isPredator(e) {
+		return predatorIcon
+	}
+	s, ok := e.(Social)
+	if !ok {
+		return objectIcon
+	}
+
 	// icon is the first character of gender
-	icon := rune(e.Gender()[0])
+	icon := rune(s.Gender()[0])
 
 	// UpperCase for those who reach middle age
-	if e.Age() < midAge {
+	if age(e) < midAge {
 		return unicode.ToLower(icon)
 	}
 	return unicode.ToUpper(icon)
 }
-
-func colorToTermbox(c PeepGender) termbox.Attribute {
-	switch c {
-	case "blue":
-		return termbox.ColorBlue
-	case "red":
-		return termbox.ColorRed
-	case "green":
-		return termbox.ColorGreen
-	case "yellow":
-		return termbox.ColorYellow
-	}
-	return termbox.ColorDefault
-}
-
-// ExisterFg returns the correct foreground color for an Exister
-func (w *World) ExisterFg(e Exister) termbox.Attribute {
-	switch e.Gender() {
-	case "blue":
-		return termbox.ColorBlue
-	case "red":
-		return termbox.ColorRed
-	case "green":
-		return termbox.ColorGreen
-	case "yellow":
-		return termbox.ColorYellow
-	}
-	return termbox.ColorDefault
-}
-
-// ExisterBg returns the correct background color for an Exister
-func (w *World) ExisterBg(e Exister) termbox.Attribute {
-	// Young ones are highlighted in white < 10 years
-	if e.Age() < w.settings.YoungHightlightAge {
-		return termbox.ColorWhite
-	}
-
-	return termbox.ColorDefault
-}
-
-// Visuals describe visual attributes for displaying an Exister
-type Visuals struct {
-	Char rune              // character displayed
-	Fg   termbox.Attribute // foreground color
-	Bg   termbox.Attribute // background color
-}
-
-// ExisterVisuals returns all the visuals for a given Exister
-func (w *World) ExisterVisuals(e Exister) *Visuals {
-	v := &Visuals{
-		Char: '*',
-		Fg:   termbox.ColorDefault,
-		Bg:   termbox.ColorDefault,
-	}
-
-	v.Char = w.ExisterIcon(e)
-	v.Fg = w.ExisterFg(e)
-	v.Bg = w.ExisterBg(e)
-
-	return v
-}
-
-func (w *World) Draw() {
-	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
-	w.DrawGrid()
-
-	flashVisuals := &Visuals{
-		Char: '☠',
-		Fg:   termbox.ColorMagenta,
-		Bg:   termbox.ColorBlack,
-	}
-
-	for _, loc := range w.grid.objects.AllNonEmptyLocations() {
-		e := w.grid.objects.GetByLocation(loc)
-		// Convert our coordinates to termbox
-		termX := int(loc.X) + int(math.Abs(float64(w.settings.Size.MinX)))
-		termY := int(loc.Y) + int(math.Abs(float64(w.settings.Size.MinY)))
-		flashForXTurns := Turn(3)
-
-		if !e.IsAlive() {
-			// Flash empty squares where peep died for 3 turns
-			if w.turn-e.DeadAtTurn() <= flashForXTurns {
-				termbox.SetCell(termX, termY, flashVisuals.Char, flashVisuals.Fg, flashVisuals.Bg)
-			}
-			continue
-		}
-
-		visuals := w.ExisterVisuals(e)
-		termbox.SetCell(termX, termY, visuals.Char, visuals.Fg, visuals.Bg)
-	}
-	termbox.Flush()
-}
-
-// DrawGrid draws borders around the world and spawn points
-func (w *World) DrawGrid() {
-	width, height := termbox.Size()
-
-	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
-
-	// Origin
-	termbox.SetCell(0, 0, ' ', termbox.ColorYellow, termbox.ColorYellow)
-
-	// top line
-	for x := 0; x <= width-2; x++ {
-		termbox.SetCell(x, 0, ' ', termbox.ColorDefault, termbox.Attribute(255))
-	}
-
-	// bottom line
-	for x := 0; x <= width-2; x++ {
-		termbox.SetCell(x, height-3, ' ', termbox.ColorDefault, termbox.Attribute(255))
-	}
-
-	// left border
-	for y := 0; y <= height-3; y++ {
-		termbox.SetCell(0, y, ' ', termbox.ColorDefault, termbox.Attribute(255))
-	}
-
-	// right border
-	for y := 0; y <= height-3; y++ {
-		termbox.SetCell(width-2, y, ' ', termbox.ColorDefault, termbox.Attribute(255))
-	}
-
-	// Homebases
-	for gender, loc := range w.homebase {
-		termX := int(loc.X) + int(math.Abs(float64(w.settings.Size.MinX)))
-		termY := int(loc.Y) + int(math.Abs(float64(w.settings.Size.MinY)))
-		termbox.SetCell(termX, termY, ' ', colorToTermbox(gender), colorToTermbox(gender))
-	}
-
-	termbox.Flush()
-}
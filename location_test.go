@@ -5,7 +5,6 @@ import (
 	"math"
 	"testing"
 
-	termbox "github.com/nsf/termbox-go"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -200,19 +199,6 @@ func TestFindEmptyLocation(t *testing.T) {
 
 }
 
-func TestColors(t *testing.T) {
-	w := genWorld()
-	peep1, _ := w.NewPeep("red", NewLocation())
-
-	Convey("Peeps is ColorRed", t, func() {
-		So(w.ExisterFg(peep1), ShouldEqual, termbox.ColorRed)
-	})
-
-	Convey("Peeps is ColorRed", t, func() {
-		So(w.ExisterBg(peep1), ShouldEqual, termbox.ColorDefault)
-	})
-}
-
 func TestSpawnLocations(t *testing.T) {
 	w := genWorld()
 
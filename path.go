@@ -0,0 +1,207 @@
+package world
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// maxPathNodes is how many cells FindPath looks at before giving up
+const maxPathNodes = 4096
+
+// pathSlack is how much further from the destination than the start a path may stray to get around obstacles
+const pathSlack = 2
+
+// FindPath returns the cheapest path for e from where it is to dst, not including its current location.
+// Every step is one cell in any direction, diagonals included, and costs what the terrain stepped on costs.
+// Across edges that join, see Topology, paths go the short way round.
+// The path goes around occupied cells, impassable terrain, the border and the homebases e keeps off, see offLimits;
+// only dst itself may be occupied, stepping on it bumps into its occupant.
+// Unless the world has impassable terrain, paths never stray more than pathSlack cells further from dst than e is now.
+// FindPath only reads the world.
+func (w *World) FindPath(e Exister, dst Location) ([]Location, error) {
+	src := e.Location()
+	if src.SameAs(dst) {
+		return []Location{}, nil
+	}
+	if w.IsBlocked(dst.X, dst.Y, dst.Z) {
+		return nil, fmt.Errorf("Location %v is outside the grid or impassable", dst)
+	}
+	if w.offLimits(e, dst) {
+		return nil, fmt.Errorf("Cannot move on top of homebase!")
+	}
+
+	limit := w.distance(src, dst) + pathSlack
+	if w.hasWalls() {
+		limit = math.MaxInt32 // getting out of a maze may mean walking away first
+	}
+	from := map[Location]Location{}
+	cost := map[Location]int32{src: 0}
+	open := &pathQueue{}
+	heap.Push(open, pathNode{src, 0, w.distance(src, dst), w.settings.distanceSquared(src, dst)})
+
+	for visited := 0; open.Len() > 0 && visited < maxPathNodes; visited++ {
+		current := heap.Pop(open).(pathNode)
+		if current.l == dst {
+			path := []Location{}
+			for l := dst; l != src; l = from[l] {
+				path = append(path, l)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path, nil
+		}
+		if current.cost > cost[current.l] {
+			continue // already reached it cheaper
+		}
+
+		for _, next := range w.pathNeighbors(e, current.l, dst) {
+			if w.distance(next, dst) > limit {
+				continue
+			}
+			c := current.cost + w.TerrainAt(next).Cost()
+			if old, ok := cost[next]; ok && old <= c {
+				continue
+			}
+			cost[next] = c
+			from[next] = current.l
+			heap.Push(open, pathNode{next, c, c + w.distance(next, dst), w.settings.distanceSquared(next, dst)})
+		}
+	}
+	return nil, fmt.Errorf("no path from %v to %v", src, dst)
+}
+
+// pathNeighbors returns the cells e could step on from l on the way to dst
+func (w *World) pathNeighbors(e Exister, l, dst Location) []Location {
+	var neighbors []Location
+	for z := l.Z - 1; z <= l.Z+1; z++ {
+		for y := l.Y - 1; y <= l.Y+1; y++ {
+			for x := l.X - 1; x <= l.X+1; x++ {
+				next := w.wrap(Location{x, y, z})
+				if next == l || w.IsBlocked(next.X, next.Y, next.Z) || w.offLimits(e, next) {
+					continue
+				}
+				if next != dst && w.IsOccupiedLocation(next) {
+					continue
+				}
+				neighbors = append(neighbors, next)
+			}
+		}
+	}
+	return neighbors
+}
+
+// pathNode is a cell reached by FindPath, with the cost to get there and the estimated total cost through it
+type pathNode struct {
+	l        Location
+	cost     int32
+	estimate int32
+	straight int64 // squared distance to the goal, so paths prefer straight lines
+}
+
+// pathQueue is a heap of cells to visit, cheapest estimate first.
+// Ties go to the straightest line, then the lowest location, so paths don't depend on map order.
+type pathQueue []pathNode
+
+func (q pathQueue) Len() int { return len(q) }
+func (q pathQueue) Less(i, j int) bool {
+	if q[i].estimate != q[j].estimate {
+		return q[i].estimate < q[j].estimate
+	}
+	if q[i].cost != q[j].cost {
+		return q[i].cost > q[j].cost // closer to the goal
+	}
+	if q[i].straight != q[j].straight {
+		return q[i].straight < q[j].straight
+	}
+	return q[i].l.Less(q[j].l)
+}
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathNode)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+// pathCache remembers the path each exister is following, so it is only searched again when blocked
+type pathCache struct {
+	lock  sync.Mutex
+	paths map[Exister]cachedPath
+}
+
+// cachedPath is what is left of a path to a destination
+type cachedPath struct {
+	dst   Location
+	steps []Location
+}
+
+// get returns the path e follows to dst, if any
+func (c *pathCache) get(e Exister, dst Location) (cachedPath, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	p, ok := c.paths[e]
+	return p, ok && p.dst == dst
+}
+
+// set remembers the path e follows
+func (c *pathCache) set(e Exister, p cachedPath) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.paths == nil {
+		c.paths = make(map[Exister]cachedPath)
+	}
+	c.paths[e] = p
+}
+
+// forget drops the path of e
+func (c *pathCache) forget(e Exister) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.paths, e)
+}
+
+// prune drops the paths of existers that can no longer move
+func (c *pathCache) prune() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for e := range c.paths {
+		if !isAlive(e) {
+			delete(c.paths, e)
+		}
+	}
+}
+
+// nextStepTo returns the x, y, z magnitude of the next step of e on its way to dst.
+// It follows the cached path of e, and searches a new one if there is none or the next step is blocked.
+// If there is no path, it falls back to nextMoveToGetFromTo.
+func (w *World) nextStepTo(e Exister, dst Location, rng *rand.Rand) (x int32, y int32, z int32) {
+	src := e.Location()
+
+	p, ok := w.paths.get(e, dst)
+	if ok {
+		// drop the steps already taken
+		for len(p.steps) > 0 && p.steps[0] == src {
+			p.steps = p.steps[1:]
+		}
+		if len(p.steps) == 0 || w.distance(src, p.steps[0]) != 1 ||
+			(p.steps[0] != dst && w.IsOccupiedLocation(p.steps[0])) {
+			ok = false // finished, pushed off it, or blocked
+		}
+	}
+	if !ok {
+		steps, err := w.FindPath(e, dst)
+		if err != nil || len(steps) == 0 {
+			w.paths.forget(e)
+			return w.nextMoveToGetFromTo(src, dst, rng)
+		}
+		p = cachedPath{dst, steps}
+	}
+	w.paths.set(e, p)
+
+	return w.settings.offset(src, p.steps[0])
+}
@@ -0,0 +1,90 @@
+package world
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFindPath(t *testing.T) {
+	w := genWorld()
+	w.SetHomebase("red", Location{9, 9, 0})
+	p, _ := w.NewPeep("red", Location{-2, 0, 0})
+
+	Convey("Paths in an empty world are straight lines.", t, func() {
+		path, err := w.FindPath(p, Location{1, 0, 0})
+		So(err, ShouldBeNil)
+		So(path, ShouldResemble, []Location{{-1, 0, 0}, {0, 0, 0}, {1, 0, 0}})
+
+		path, err = w.FindPath(p, Location{-2, 0, 0})
+		So(err, ShouldBeNil)
+		So(path, ShouldBeEmpty)
+	})
+
+	Convey("Paths go around peeps in the way, and may end on one.", t, func() {
+		for y := int32(-1); y <= 1; y++ {
+			w.NewPeep("blue", Location{-1, y, 0})
+		}
+		path, err := w.FindPath(p, Location{1, 0, 0})
+		So(err, ShouldBeNil)
+		So(path, ShouldResemble, []Location{{-2, -1, 0}, {-1, -2, 0}, {0, -1, 0}, {1, 0, 0}})
+
+		path, err = w.FindPath(p, Location{-1, 0, 0})
+		So(err, ShouldBeNil)
+		So(path, ShouldResemble, []Location{{-1, 0, 0}})
+	})
+
+	Convey("Paths never cross the border or the homebase.", t, func() {
+		far, _ := w.NewPeep("red", Location{9, 7, 0})
+		path, err := w.FindPath(far, Location{9, 4, 0})
+		So(err, ShouldBeNil)
+		So(path, ShouldResemble, []Location{{9, 6, 0}, {9, 5, 0}, {9, 4, 0}})
+
+		_, err = w.FindPath(far, Location{9, 9, 0})
+		So(err, ShouldNotBeNil)
+		_, err = w.FindPath(far, Location{10, 7, 0})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("There is no path into a closed box.", t, func() {
+		for _, l := range w.LocationNeighbors(Location{-5, -5, 0}, 1) {
+			w.NewPeep("green", l)
+		}
+		_, err := w.FindPath(p, Location{-5, -5, 0})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestNextStepTo(t *testing.T) {
+	w := genWorld()
+	p, _ := w.NewPeep("red", Location{0, 1, 0})
+	dst := Location{4, 1, 0}
+
+	Convey("Peeps follow their path, one step per turn.", t, func() {
+		x, y, z := w.nextStepTo(p, dst, w.random)
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, 0, 0})
+		So(w.Move(p, x, y, z), ShouldBeNil)
+
+		x, y, z = w.nextStepTo(p, dst, w.random)
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, 0, 0})
+		path, _ := w.paths.get(p, dst)
+		So(path.steps, ShouldResemble, []Location{{2, 1, 0}, {3, 1, 0}, {4, 1, 0}})
+	})
+
+	Convey("A blocked path is searched again.", t, func() {
+		w.NewPeep("blue", Location{2, 1, 0})
+		x, y, _ := w.nextStepTo(p, dst, w.random)
+		So(x, ShouldEqual, 1)
+		So(y, ShouldNotEqual, 0)
+		path, _ := w.paths.get(p, dst)
+		So(path.steps[0], ShouldResemble, Location{2, 1 + y, 0})
+		So(len(path.steps), ShouldEqual, 3)
+	})
+
+	Convey("Paths of dead peeps are dropped.", t, func() {
+		p.Die(w.turn)
+		w.paths.prune()
+		_, ok := w.paths.get(p, dst)
+		So(ok, ShouldBeFalse)
+	})
+}
@@ -1,11 +1,12 @@
 package world
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"math"
-	"math/rand"
 
-	"github.com/nu7hatch/gouuid"
+	"github.com/google/uuid"
 )
 
 var (
@@ -26,53 +27,108 @@ type Peep struct {
 	world      *World               // reference to world
 	neighbors  map[Location]Exister // neighbors at time of last lookup
 	spawnTurn  Turn                 // the turn of last spawn
+	deathCause DeathCause           // why the peep died
+	energy     float64              // drained by living and moving, replenished by eating
+	genome     Genome               // inherited from its parents
 }
 
 func (w *World) Genders() []PeepGender {
-	return genders[0:w.settings.MaxGenders]
+	return w.settings.genders()
+}
+
+// genders returns the genders available with these settings
+func (s Settings) genders() []PeepGender {
+	return genders[0:s.MaxGenders]
+}
+
+// containsGender returns true if gender is in the list
+func containsGender(list []PeepGender, gender PeepGender) bool {
+	for _, g := range list {
+		if g == gender {
+			return true
+		}
+	}
+	return false
 }
 
 func (w *World) SetHomebase(gender PeepGender, loc Location) {
 	w.homebase[gender] = loc
 }
 
-// NewPeep creates and returns a new peep
+// NewPeep creates and returns a new peep at location
 func (w *World) NewPeep(gender PeepGender, location Location) (*Peep, error) {
+	return w.spawnPeep(gender, &location, 0, nil)
+}
+
+// NewPeepAtHomebase creates and returns a new peep on its homebase.
+// If gender is empty, one is picked at random.
+func (w *World) NewPeepAtHomebase(gender PeepGender) (*Peep, error) {
+	return w.spawnPeep(gender, nil, 0, nil)
+}
+
+// spawnPeep creates and returns a new peep of the given age, child of parents
+// If gender is empty, one is picked at random. If location is nil, the peep is born on its homebase.
+func (w *World) spawnPeep(gender PeepGender, at *Location, age PeepAge, parents []Exister) (*Peep, error) {
 	// MaxPeeps already
 	if w.AlivePeepCount() >= w.settings.MaxPeeps {
 		return nil, fmt.Errorf("cannot create new peep, MaxPeeps already present")
 	}
 
 	if gender == "" {
-		gender = genders[rand.Intn(len(w.Genders()))]
+		gender = genders[w.random.Intn(len(w.Genders()))]
 	}
-	u, err := uuid.NewV4()
+	id, err := w.newID()
 	if err != nil {
 		return nil, err
 	}
 	peep := &Peep{
-		id:        u.String(),
+		id:        id,
+		age:       age,
 		isalive:   true,
 		gender:    gender,
 		met:       make(map[Exister]Turn),
 		world:     w,
 		neighbors: make(map[Location]Exister),
+		energy:    w.settings.MaxEnergy,
+		genome:    w.inherit(parents),
 	}
 	// If no specific location set, pick one based on gender
-	if location.SameAs(Location{}) {
-		location = w.SpawnPoint(peep)
+	if at == nil {
+		home := w.SpawnPoint(peep)
+		at = &home
+	}
+	location := *at
+
+	if w.IsBlocked(location.X, location.Y, location.Z) {
+		return nil, fmt.Errorf("cannot create new peep, %v is outside the grid or impassable", location)
 	}
 
 	// Check if spawn point is busy.
-	e := w.grid.objects.GetByLocation(location)
-	if e != nil && e.IsAlive() {
+	e := w.grid.At(location)
+	if e != nil && isPresent(e) {
 		return nil, fmt.Errorf("cannot crate new peep, origin taken by: %v", e.ID())
 	}
 
 	w.UpdateGrid(peep, location, location)
+	w.recordBirth(peep, parents)
+	w.emitBirth(peep)
 	return peep, nil
 }
 
+// newID returns a new unique id drawn from the world's random source,
+// so that the same seed always produces the same ids.
+func (w *World) newID() (string, error) {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], w.random.Uint64())
+	binary.BigEndian.PutUint64(b[8:], w.random.Uint64())
+
+	u, err := uuid.NewRandomFromReader(bytes.NewReader(b[:]))
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
 // Location returns this peep's location
 func (p *Peep) Location() Location {
 	l, _ := p.world.ExisterLocation(p)
@@ -85,18 +141,12 @@ func (p *Peep) NeighborsFromLook() map[Location]Exister {
 }
 
 // SetNeighbors sets exister's neighbors right now
-// All neighbors in the radius of world.settings.PeepViewDistance are returned
+// All neighbors in the radius of the peep's view distance (see Genome) are returned
 func (p *Peep) SetNeighbors() {
 
-	locations := p.world.LocationNeighbors(p.Location(), p.world.settings.PeepViewDistance)
-
-	for _, l := range locations {
-		if p.Location().SameAs(l) {
-			continue
-		}
-		e := p.world.LocationExister(l)
-		if e != nil && e.IsAlive() { // don't care about dead existers
-			p.neighbors[l] = e
+	for _, e := range p.world.existersAround(p.Location(), p.genome.ViewDistance) {
+		if isAlive(e) { // don't care about dead existers, or things that never lived
+			p.neighbors[e.Location()] = e
 		}
 	}
 }
@@ -126,6 +176,21 @@ func (p *Peep) SetLookTurn(t Turn) {
 	p.lookTurn = t
 }
 
+// Energy returns the energy the peep has left
+func (p *Peep) Energy() float64 {
+	return p.energy
+}
+
+// SetEnergy sets the energy the peep has left
+func (p *Peep) SetEnergy(energy float64) {
+	p.energy = energy
+}
+
+// Genome returns what the peep inherited from its parents
+func (p *Peep) Genome() Genome {
+	return p.genome
+}
+
 func (peep *Peep) ID() string {
 	return peep.id
 }
@@ -179,9 +244,42 @@ func (peep *Peep) MetPeep(other Exister) bool {
 
 // Die kills the peep
 func (peep *Peep) Die(turn Turn) {
-	// Log("Peep: ", peep.ID(), " died!")
+	peep.dieOf(turn, "")
+}
+
+// dieOf kills the peep and records why
+func (peep *Peep) dieOf(turn Turn, cause DeathCause) {
 	peep.isalive = false
 	peep.deadAtTurn = turn
+	peep.deathCause = cause
+}
+
+// lineage returns the family record of the peep
+func (peep *Peep) lineage() *Lineage {
+	if l, ok := peep.world.family.get(peep.id); ok {
+		return l
+	}
+	return &Lineage{ID: peep.id, Gender: peep.gender}
+}
+
+// Parents returns the ids of the peeps this one spawned from
+func (peep *Peep) Parents() []string {
+	return peep.lineage().Parents
+}
+
+// Generation returns how many generations of peeps came before this one
+func (peep *Peep) Generation() int {
+	return peep.lineage().Generation
+}
+
+// Children returns the ids of the peeps spawned from this one, in order of birth
+func (peep *Peep) Children() []string {
+	return peep.lineage().Children
+}
+
+// CauseOfDeath returns why the peep died, if it did
+func (peep *Peep) CauseOfDeath() DeathCause {
+	return peep.deathCause
 }
 
 // Gender returns the peep's gender
@@ -193,12 +291,12 @@ func (peep *Peep) Gender() PeepGender {
 // An error is return on death
 func (peep *Peep) AgeOrDie(maxage PeepAge, randomdeath float64, turn Turn) (PeepAge, error) {
 	if peep.age >= maxage {
-		peep.Die(turn)
+		peep.dieOf(turn, DeathOldAge)
 		return peep.Age(), fmt.Errorf("Peep died, too old...")
 	}
 	// Older peeps have more chances to die
-	if randomdeath > 0 && rand.Float64() < randomdeath+(math.Log10(float64(peep.age))/float64(maxage/1)) {
-		peep.Die(turn)
+	if randomdeath > 0 && peep.world.random.Float64() < randomdeath+(math.Log10(float64(peep.age))/float64(maxage/1)) {
+		peep.dieOf(turn, DeathRandom)
 		return peep.Age(), fmt.Errorf("Peep died, randomness sucks...")
 	}
 	peep.AddAge()
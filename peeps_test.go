@@ -8,16 +8,22 @@ import (
 
 func TestNewPeep(t *testing.T) {
 	w := genWorld()
+	w.SetHomebase("red", Location{5, 5, 0})
 
 	Convey("NewPeep is born.", t, func() {
-		peep1, err := w.NewPeep("red", Location{})
+		peep1, err := w.NewPeepAtHomebase("red")
 		So(err, ShouldBeNil)
 		So(peep1.Gender(), ShouldEqual, "red")
+		So(peep1.Location(), ShouldResemble, w.homebase["red"])
+
+		origin, err := w.NewPeep("red", Location{})
+		So(err, ShouldBeNil)
+		So(origin.Location(), ShouldResemble, Location{})
 	})
 
-	w.settings.MaxPeeps = 1
+	w.settings.MaxPeeps = 2
 	Convey("NewPeep fails to be born, too many already", t, func() {
-		_, err := w.NewPeep("red", Location{})
+		_, err := w.NewPeepAtHomebase("red")
 		So(err, ShouldNotBeNil)
 	})
 }
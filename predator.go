@@ -0,0 +1,352 @@
+package world
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// predatorIcon is how predators are drawn, see ExisterIcon
+const predatorIcon = 'X'
+
+// Predator hunts peeps. It chases the closest peep it sees and eats it on contact, see World.Meet.
+// Predators live on the peeps they eat: they starve without prey and spawn once fed, see Settings.Predators.
+type Predator struct {
+	id         string // unique id
+	age        PeepAge
+	isalive    bool
+	deadAtTurn Turn                 // World turn when the predator died
+	deathCause DeathCause           // why the predator died
+	lookTurn   Turn                 // the turn when this predator looked around
+	spawnTurn  Turn                 // the turn of last spawn
+	energy     float64              // drained by living, replenished by eating peeps
+	genome     Genome               // inherited from its parent
+	world      *World               // reference to world
+	neighbors  map[Location]Exister // neighbors at time of last lookup
+}
+
+// predatorsEnabled returns true if the world starts with predators, see Settings.Predators
+func (s Settings) predatorsEnabled() bool {
+	return s.Predators > 0
+}
+
+// predatorGenome returns the genome of predators without a parent, taken from the settings
+func (s Settings) predatorGenome() Genome {
+	return Genome{
+		ViewDistance: s.PredatorViewDistance,
+		Lifespan:     s.PredatorMaxAge,
+		Fertility:    1,
+		Speed:        1,
+		Sociability:  1,
+	}
+}
+
+// isPeep returns true if e is a peep
+func isPeep(e Exister) bool {
+	_, ok := e.(*Peep)
+	return ok
+}
+
+// isPredator returns true if e is a predator
+func isPredator(e Exister) bool {
+	_, ok := e.(*Predator)
+	return ok
+}
+
+// isHomebase returns true if l is the homebase of any gender
+func (w *World) isHomebase(l Location) bool {
+	for _, h := range w.homebase {
+		if h == l {
+			return true
+		}
+	}
+	return false
+}
+
+// offLimits returns true if e may not step on l: peeps keep off their own homebase, predators off all of them
+func (w *World) offLimits(e Exister, l Location) bool {
+	if isPredator(e) {
+		return w.isHomebase(l)
+	}
+	if s, ok := e.(Social); ok {
+		return s.Homebase().SameAs(l)
+	}
+	return false
+}
+
+// NewPredator creates and returns a new predator at location
+func (w *World) NewPredator(location Location) (*Predator, error) {
+	return w.spawnPredator(location, nil)
+}
+
+// spawnPredator creates and returns a new predator at location.
+// A child gets half the energy of its parent, predators without a parent get half of Settings.PredatorEnergy.
+func (w *World) spawnPredator(location Location, parent *Predator) (*Predator, error) {
+	if w.settings.MaxPredators > 0 && w.AlivePredatorCount() >= w.settings.MaxPredators {
+		return nil, fmt.Errorf("cannot create new predator, MaxPredators already present")
+	}
+	if w.IsBlocked(location.X, location.Y, location.Z) {
+		return nil, fmt.Errorf("cannot create new predator, %v is outside the grid or impassable", location)
+	}
+	if w.isHomebase(location) {
+		return nil, fmt.Errorf("cannot create new predator on a homebase: %v", location)
+	}
+	if e := w.grid.At(location); e != nil && isPresent(e) {
+		return nil, fmt.Errorf("cannot create new predator, %v taken by: %v", location, e.ID())
+	}
+
+	id, err := w.newID()
+	if err != nil {
+		return nil, err
+	}
+	p := &Predator{
+		id:        id,
+		isalive:   true,
+		energy:    w.settings.PredatorEnergy / 2,
+		genome:    w.settings.predatorGenome(),
+		world:     w,
+		neighbors: make(map[Location]Exister),
+	}
+	var parents []string
+	if parent != nil {
+		p.genome = w.inherit([]Exister{parent})
+		p.energy = parent.energy / 2
+		parent.energy -= p.energy
+		parent.spawnTurn = w.turn
+		parents = []string{parent.ID()}
+	}
+
+	w.UpdateGrid(p, location, location)
+	event := predatorBirthEvent(p)
+	event.Parents = parents
+	w.emit(event)
+	return p, nil
+}
+
+// PlacePredators places Settings.Predators new predators at random free cells, away from homebases.
+// Call it once the terrain and homebases of the world are set.
+func (w *World) PlacePredators() error {
+	var free []Location
+	var z int32 // World is flat for now.
+	for x := w.MinX(); x <= w.MaxX(); x++ {
+		for y := w.MinY(); y <= w.MaxY(); y++ {
+			l := Location{x, y, z}
+			if !w.IsBlocked(x, y, z) && !w.IsOccupiedLocation(l) && !w.isHomebase(l) {
+				free = append(free, l)
+			}
+		}
+	}
+
+	for i := 0; i < w.settings.Predators; i++ {
+		if len(free) == 0 {
+			return fmt.Errorf("no room left for predator %v of %v", i+1, w.settings.Predators)
+		}
+		n := w.random.Intn(len(free))
+		if _, err := w.NewPredator(free[n]); err != nil {
+			return err
+		}
+		free[n] = free[len(free)-1]
+		free = free[:len(free)-1]
+	}
+	return nil
+}
+
+// AlivePredatorCount returns the number of alive predators
+func (w *World) AlivePredatorCount() int64 {
+	return w.grid.count(func(e Exister) bool {
+		return isPredator(e) && isAlive(e)
+	})
+}
+
+// agePredator ages p and makes it pay for living, see Settings.PredatorHunger.
+// Predators die of old age or hunger, and spawn next to them when they were full of energy at the start of the turn.
+func (w *World) agePredator(p *Predator) {
+	if p.age >= p.genome.Lifespan {
+		w.killPredator(p, DeathOldAge)
+		return
+	}
+	p.age++
+
+	full := p.energy >= w.settings.PredatorEnergy // eating fills predators up to PredatorEnergy, before hunger bites
+	p.energy -= w.settings.PredatorHunger
+	if p.energy <= 0 {
+		w.killPredator(p, DeathStarvation)
+		return
+	}
+
+	if full && (p.genome.Fertility >= 1 || w.random.Float64() < p.genome.Fertility) {
+		for _, l := range w.LocationNeighbors(p.Location(), 1) {
+			if !w.IsOccupiedLocation(l) && !w.isHomebase(l) {
+				w.spawnPredator(l, p) // fails only when there are enough predators
+				break
+			}
+		}
+	}
+}
+
+// killPredator kills a predator, unless it is already dead
+func (w *World) killPredator(p *Predator, cause DeathCause) {
+	if !p.IsAlive() {
+		return
+	}
+	p.isalive = false
+	p.deadAtTurn = w.turn
+	p.deathCause = cause
+
+	loc := p.Location()
+	w.emit(Event{Type: EventPredatorDeath, ID: p.ID(), Age: p.Age(), Location: &loc, Cause: cause})
+}
+
+// hunt is what happens when a predator meets anything: a peep it bumps into, or that bumps into it, is eaten.
+// It returns false if neither left nor right is a predator.
+func (w *World) hunt(left, right Exister) bool {
+	predator, ok := left.(*Predator)
+	prey := right
+	if !ok {
+		if predator, ok = right.(*Predator); !ok {
+			return false
+		}
+		prey = left
+	}
+
+	peep, ok := prey.(*Peep)
+	if !ok || !predator.IsAlive() || !peep.IsAlive() {
+		return true // predators leave each other alone
+	}
+	loc := peep.Location()
+	w.emit(Event{Type: EventKill, ID: predator.ID(), Other: peep.ID(), Location: &loc})
+	w.kill(peep, DeathEaten)
+	predator.energy = math.Min(predator.energy+w.settings.PredatorFeed, w.settings.PredatorEnergy)
+	return true
+}
+
+// nearestOf returns the closest of neighbors to l for which keep returns true, nil if there is none.
+// Ties go to the lowest location.
+func (w *World) nearestOf(l Location, neighbors map[Location]Exister, keep func(Exister) bool) Exister {
+	var nearest Exister
+	var nearestLoc Location
+	for nl, n := range neighbors {
+		if !keep(n) {
+			continue
+		}
+		if nearest != nil {
+			d, nd := w.distance(l, nl), w.distance(l, nearestLoc)
+			if d > nd || (d == nd && !nl.Less(nearestLoc)) {
+				continue
+			}
+		}
+		nearest, nearestLoc = n, nl
+	}
+	return nearest
+}
+
+// bestPredatorMove returns where a predator seeing neighbors goes: after the closest peep, or anywhere if there is none
+func (w *World) bestPredatorMove(e Exister, neighbors map[Location]Exister, rng *rand.Rand) (x int32, y int32, z int32) {
+	if prey := w.nearestOf(e.Location(), neighbors, isPeep); prey != nil {
+		return w.nextStepTo(e, prey.Location(), rng)
+	}
+	return randomMove(rng)
+}
+
+// preyInSight is 1 if a predator saw a peep when it last looked around, lower otherwise
+var preyInSight = Consideration{"prey_in_sight", func(w *World, e Exister) float64 {
+	p, ok := e.(Perceiver)
+	if !ok {
+		return 0.75
+	}
+	for _, n := range p.NeighborsFromLook() {
+		if isPeep(n) && isAlive(n) {
+			return 1
+		}
+	}
+	return 0.75
+}}
+
+// Location returns this predator's location
+func (p *Predator) Location() Location {
+	l, _ := p.world.ExisterLocation(p)
+	return l
+}
+
+// NeighborsFromLook returns the map of neighbors at time of last lookup
+func (p *Predator) NeighborsFromLook() map[Location]Exister {
+	return p.neighbors
+}
+
+// SetNeighbors sets the predator's neighbors right now, as far as its genome lets it see
+func (p *Predator) SetNeighbors() {
+	for _, e := range p.world.existersAround(p.Location(), p.genome.ViewDistance) {
+		if isAlive(e) {
+			p.neighbors[e.Location()] = e
+		}
+	}
+}
+
+// SpawnTurn returns the last time the predator spawned
+func (p *Predator) SpawnTurn() Turn {
+	return p.spawnTurn
+}
+
+// SetSpawnTurn sets the spawn turn
+func (p *Predator) SetSpawnTurn(t Turn) {
+	p.spawnTurn = t
+}
+
+// World returns pointer to the world the exister is in
+func (p *Predator) World() *World {
+	return p.world
+}
+
+// LookTurn returns the last turn that the predator looked around
+func (p *Predator) LookTurn() Turn {
+	return p.lookTurn
+}
+
+// SetLookTurn sets the last turn this predator looked around
+func (p *Predator) SetLookTurn(t Turn) {
+	p.lookTurn = t
+}
+
+// Energy returns the energy the predator has left
+func (p *Predator) Energy() float64 {
+	return p.energy
+}
+
+// SetEnergy sets the energy the predator has left
+func (p *Predator) SetEnergy(energy float64) {
+	p.energy = energy
+}
+
+// Genome returns what the predator inherited from its parent
+func (p *Predator) Genome() Genome {
+	return p.genome
+}
+
+// ID returns the predator's id
+func (p *Predator) ID() string {
+	return p.id
+}
+
+func (p *Predator) String() string {
+	return fmt.Sprintf("%v predator age:%v location:%v", p.ID(), p.Age(), p.Location())
+}
+
+// IsAlive returns true if the predator is alive
+func (p *Predator) IsAlive() bool {
+	return p.isalive
+}
+
+// Age returns the predator's age
+func (p *Predator) Age() PeepAge {
+	return p.age
+}
+
+// DeadAtTurn returns the turn the predator died
+func (p *Predator) DeadAtTurn() Turn {
+	return p.deadAtTurn
+}
+
+// CauseOfDeath returns why the predator died, if it did
+func (p *Predator) CauseOfDeath() DeathCause {
+	return p.deathCause
+}
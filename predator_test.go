@@ -0,0 +1,197 @@
+package world
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// genPredatorWorld returns a seeded world where predators hunt peeps
+func genPredatorWorld(seed int64) *World {
+	s := genSeededWorld(seed).settings
+	s.Predators = 3
+	s.PredatorMaxAge = 60
+	s.PredatorViewDistance = 3
+	s.PredatorEnergy = 40
+	s.PredatorHunger = 0.5
+	s.PredatorFeed = 10
+	w := NewWorld("Hunted", s, false)
+	w.SetDefaultHomebases()
+	w.PlacePredators()
+	return w
+}
+
+func TestPredatorHunt(t *testing.T) {
+	w := genWorld()
+	enableMoves(t)
+	w.settings.PredatorMaxAge = 10
+	w.settings.PredatorViewDistance = 3
+	w.settings.PredatorEnergy = 20
+	w.settings.PredatorFeed = 5
+	w.SetHomebase("red", Location{-9, -9, 0})
+	r := &eventRecorder{}
+	w.AddEventSink(r)
+
+	predator, err := w.NewPredator(Location{0, 0, 0})
+	peep, _ := w.NewPeep("red", Location{3, 0, 0})
+
+	Convey("Predators are born with half their energy, and never on homebases.", t, func() {
+		So(err, ShouldBeNil)
+		So(predator.Energy(), ShouldEqual, 10)
+		So(predator.Genome().ViewDistance, ShouldEqual, 3)
+		So(r.ofType(EventPredatorBirth), ShouldHaveLength, 1)
+
+		_, err := w.NewPredator(Location{-9, -9, 0})
+		So(err, ShouldNotBeNil)
+		_, err = w.NewPredator(Location{3, 0, 0})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Predators chase the closest peep they see, and peeps run away.", t, func() {
+		x, y, z := w.bestPredatorMove(predator, w.visibleNeighbors(predator), w.random)
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, 0, 0})
+		So(w.action("move").Score(w, predator).Considerations, ShouldContain, Rating{"prey_in_sight", 0.75})
+
+		w.LookAround(predator)
+		So(w.action("move").Score(w, predator).Considerations, ShouldContain, Rating{"prey_in_sight", 1})
+		So(eatAction{}.Score(w, predator).Value, ShouldEqual, 0)
+
+		So(w.Move(predator, 1, 0, 0), ShouldBeNil)
+		x, y, z = w.bestPeepMove(peep, w.visibleNeighbors(peep), w.random)
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, 0, 0})
+	})
+
+	Convey("Peeps that meet a predator are eaten.", t, func() {
+		So(w.Move(predator, 1, 0, 0), ShouldBeNil)
+		So(w.Move(predator, 1, 0, 0), ShouldNotBeNil) // bumps into the peep
+		So(peep.IsAlive(), ShouldBeFalse)
+		So(peep.CauseOfDeath(), ShouldEqual, DeathEaten)
+		So(predator.Energy(), ShouldEqual, 15)
+
+		kills := r.ofType(EventKill)
+		So(kills, ShouldHaveLength, 1)
+		So(kills[0].ID, ShouldEqual, predator.ID())
+		So(kills[0].Other, ShouldEqual, peep.ID())
+
+		other, _ := w.NewPeep("red", Location{2, 1, 0})
+		So(w.Move(other, 0, -1, 0), ShouldNotBeNil) // bumps into the predator
+		So(other.CauseOfDeath(), ShouldEqual, DeathEaten)
+		So(predator.Energy(), ShouldEqual, 20) // no more than PredatorEnergy
+	})
+
+	Convey("Predators keep off homebases.", t, func() {
+		hunter, _ := w.NewPredator(Location{-8, -8, 0})
+		So(w.Move(hunter, -1, -1, 0), ShouldNotBeNil)
+		_, err := w.FindPath(hunter, Location{-9, -9, 0})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestPredatorLife(t *testing.T) {
+	w := genWorld()
+	w.settings.PredatorMaxAge = 3
+	w.settings.PredatorEnergy = 10
+	w.settings.PredatorHunger = 1
+	w.settings.PredatorFeed = 5
+	w.settings.MaxPredators = 2
+	r := &eventRecorder{}
+	w.AddEventSink(r)
+
+	predator, _ := w.NewPredator(Location{0, 0, 0})
+
+	Convey("Well fed predators spawn next to them, sharing their energy.", t, func() {
+		predator.SetEnergy(10) // as full as eating gets it
+		w.NextTurn()
+		So(w.AlivePredatorCount(), ShouldEqual, 2)
+		So(predator.Energy(), ShouldEqual, 4.5)
+		So(predator.SpawnTurn(), ShouldEqual, 1)
+
+		births := r.ofType(EventPredatorBirth)
+		So(births[len(births)-1].Parents, ShouldResemble, []string{predator.ID()})
+		child := w.LocationExister(*births[len(births)-1].Location)
+		So(child.(Living).Energy(), ShouldEqual, 4.5)
+
+		predator.SetEnergy(11)
+		w.NextTurn()
+		So(w.AlivePredatorCount(), ShouldEqual, 2) // MaxPredators
+	})
+
+	Convey("Predators starve without prey and die of old age.", t, func() {
+		predator.SetEnergy(1)
+		w.NextTurn()
+		So(predator.IsAlive(), ShouldBeFalse)
+		So(predator.CauseOfDeath(), ShouldEqual, DeathStarvation)
+
+		old, _ := w.NewPredator(Location{5, 5, 0})
+		old.age = 3
+		w.NextTurn()
+		So(old.CauseOfDeath(), ShouldEqual, DeathOldAge)
+
+		deaths := r.ofType(EventPredatorDeath)
+		So(deaths[len(deaths)-1].Cause, ShouldEqual, DeathOldAge)
+		So(r.ofType(EventDeath), ShouldBeEmpty) // peep deaths only
+	})
+}
+
+func TestPredatorsArePeepsApart(t *testing.T) {
+	w := genWorld()
+	w.NewPeep("red", Location{1, 1, 0})
+	predator, _ := w.NewPredator(Location{5, 5, 0})
+	w.Publish()
+
+	Convey("Predators are not counted or served as peeps.", t, func() {
+		So(w.AlivePeepCount(), ShouldEqual, 1)
+		So(w.AlivePredatorCount(), ShouldEqual, 1)
+		So(w.PeepGenders(), ShouldResemble, map[PeepGender]int64{"red": 1})
+		So(w.PeepMinAge(), ShouldEqual, 0)
+		So(w.View().Peeps, ShouldHaveLength, 1)
+
+		var info WorldInfo
+		So(get(w, "/api/world", &info), ShouldEqual, http.StatusOK)
+		So(info.Alive, ShouldEqual, 1)
+		So(info.Predators, ShouldEqual, 1)
+	})
+
+	Convey("Predators are drawn as predators.", t, func() {
+		cells := w.Frame().Cells
+		So(cells, ShouldHaveLength, 2)
+		So(cells[1].ID, ShouldEqual, predator.ID())
+		So(cells[1].Predator, ShouldBeTrue)
+		So(cells[1].Icon, ShouldEqual, predatorIcon)
+		So(cellVisuals(cells[1]).Char, ShouldEqual, predatorIcon)
+	})
+}
+
+func TestPredatorPrey(t *testing.T) {
+	enableMoves(t)
+
+	Convey("Predators are placed away from homebases and hunt peeps.", t, func() {
+		w := genPredatorWorld(3)
+		So(w.AlivePredatorCount(), ShouldEqual, 3)
+		for _, e := range w.allExisters() {
+			So(w.isHomebase(e.Location()), ShouldBeFalse)
+		}
+
+		r := &eventRecorder{}
+		w.AddEventSink(r)
+		turnHistory(w, 100)
+		So(r.ofType(EventKill), ShouldNotBeEmpty)
+		So(len(r.ofType(EventPredatorBirth)), ShouldBeGreaterThan, 0) // well fed predators spawn
+	})
+
+	Convey("Predators are saved and the world continues like the original.", t, func() {
+		original := genPredatorWorld(5)
+		turnHistory(original, 50)
+
+		var saved bytes.Buffer
+		So(original.Snapshot(&saved), ShouldBeNil)
+		restored, err := LoadWorld(bytes.NewReader(saved.Bytes()), false)
+		So(err, ShouldBeNil)
+		So(restored.Frame(), ShouldResemble, original.Frame())
+
+		So(turnHistory(restored, 50), ShouldResemble, turnHistory(original, 50))
+		So(restored.Frame(), ShouldResemble, original.Frame())
+	})
+}
@@ -0,0 +1,117 @@
+package world
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+)
+
+// randomSource is a rand.Source that is reseeded every turn from the seed of the world and the turn,
+// and counts how many values were drawn since, so that its state can be saved as (seed, turn, draws)
+// and restored later without replaying every draw of the world.
+type randomSource struct {
+	src   rand.Source64
+	seed  int64
+	turn  Turn   // the turn the source was last reseeded for, 0 means it was seeded with seed itself
+	draws uint64 // since the source was last reseeded
+}
+
+// newRandomSource returns a new source seeded with seed
+func newRandomSource(seed int64) *randomSource {
+	return &randomSource{
+		src:  rand.NewSource(seed).(rand.Source64),
+		seed: seed,
+	}
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer
+func (s *randomSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+// Uint64 returns a pseudo-random 64-bit integer
+func (s *randomSource) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+// Seed reseeds the source and resets the turn and the number of draws
+func (s *randomSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.seed = seed
+	s.turn = 0
+	s.draws = 0
+}
+
+// reseed starts the draws of turn, they only depend on the seed and the turn
+func (s *randomSource) reseed(turn Turn) {
+	if turn == 0 {
+		s.Seed(s.seed)
+		return
+	}
+
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(s.seed))
+	binary.BigEndian.PutUint64(b[8:], uint64(turn))
+	h := fnv.New64a()
+	h.Write(b[:])
+
+	s.src.Seed(int64(h.Sum64()))
+	s.turn = turn
+	s.draws = 0
+}
+
+// restore puts the source in the state it was in after draws values were drawn on turn
+func (s *randomSource) restore(seed int64, turn Turn, draws uint64) {
+	s.Seed(seed)
+	s.reseed(turn)
+	for s.draws < draws {
+		s.Uint64()
+	}
+}
+
+// splitMix is a small, fast rand.Source64 (SplitMix64), cheap enough to create one per peep and turn
+type splitMix struct {
+	state uint64
+}
+
+// Uint64 returns a pseudo-random 64-bit integer
+func (s *splitMix) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer
+func (s *splitMix) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// Seed reseeds the source
+func (s *splitMix) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// turnHash returns a number derived from the world seed, the current turn, id and salt.
+// It is the same every time the same world reaches the same turn.
+func (w *World) turnHash(id, salt string) uint64 {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(w.settings.Seed))
+	binary.BigEndian.PutUint64(b[8:], uint64(w.turn))
+
+	h := fnv.New64a()
+	h.Write(b[:])
+	h.Write([]byte(id))
+	h.Write([]byte(salt))
+	return h.Sum64()
+}
+
+// TurnRandom returns a random source for e on this turn, name keeps the draws of different actions apart.
+// The same world gives the same numbers every time it reaches this turn.
+// Unlike the world's random source it can be used from any goroutine, see Action.Score.
+func (w *World) TurnRandom(e Exister, name string) *rand.Rand {
+	return rand.New(&splitMix{state: w.turnHash(e.ID(), "random/"+name)})
+}
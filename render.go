@@ -0,0 +1,76 @@
+package world
+
+// flashForXTurns is how long the spot where a peep died is highlighted
+const flashForXTurns = Turn(3)
+
+// Renderer draws the world.
+// Draw is called by the world at the end of every turn.
+type Renderer interface {
+	Draw(f *Frame)
+}
+
+// NoopRenderer draws nothing, it is used for headless runs.
+type NoopRenderer struct{}
+
+// Draw does nothing
+func (NoopRenderer) Draw(f *Frame) {}
+
+// Frame is a picture of the world at the end of a turn, as handed to Renderers.
+// Frames are never changed once handed out, so renderers may keep them.
+type Frame struct {
+	Turn      Turn
+	Size      Size
+	Topology  Topology // which edges of the grid join, see Settings.Topology
+	Homebases map[PeepGender]Location
+	Terrain   []TerrainCell // cells that aren't plain ground, ordered by location
+	Cells     []Cell        // occupied cells, ordered by location
+}
+
+// Cell describes one occupied cell of the grid
+type Cell struct {
+	Location Location   `json:"location"`
+	ID       string     `json:"id"`
+	Gender   PeepGender `json:"gender"`
+	Age      PeepAge    `json:"age"`
+	Icon     rune       `json:"icon"`               // see ExisterIcon
+	Young    bool       `json:"young"`              // younger than settings.YoungHightlightAge
+	Dead     bool       `json:"dead"`               // the occupant died within the last flashForXTurns turns
+	Predator bool       `json:"predator,omitempty"` // the occupant is a predator, see Predator
+}
+
+// Frame returns a picture of the world as it is right now.
+// Dead existers are only included for flashForXTurns turns after their death.
+func (w *World) Frame() *Frame {
+	f := &Frame{
+		Turn:      w.turn,
+		Size:      *w.settings.Size,
+		Topology:  w.settings.Topology,
+		Homebases: make(map[PeepGender]Location),
+		Terrain:   w.terrainCells, // never changed in place
+	}
+	for gender, loc := range w.homebase {
+		f.Homebases[gender] = loc
+	}
+
+	for _, e := range w.allExisters() {
+		c := Cell{
+			Location: e.Location(),
+			ID:       e.ID(),
+			Icon:     w.ExisterIcon(e),
+			Predator: isPredator(e),
+		}
+		if l, ok := e.(Living); ok {
+			if !l.IsAlive() && w.turn-l.DeadAtTurn() > flashForXTurns {
+				continue
+			}
+			c.Age = l.Age()
+			c.Young = l.Age() < w.settings.YoungHightlightAge
+			c.Dead = !l.IsAlive()
+		}
+		if s, ok := e.(Social); ok {
+			c.Gender = s.Gender()
+		}
+		f.Cells = append(f.Cells, c)
+	}
+	return f
+}
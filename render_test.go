@@ -0,0 +1,95 @@
+package world
+
+import (
+	"testing"
+
+	termbox "github.com/nsf/termbox-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recordingRenderer keeps every frame it is asked to draw
+type recordingRenderer struct {
+	frames []*Frame
+}
+
+func (r *recordingRenderer) Draw(f *Frame) {
+	r.frames = append(r.frames, f)
+}
+
+func TestFrame(t *testing.T) {
+	w := genWorld()
+	w.SetHomebase("red", Location{3, 4, 0})
+	peep1, _ := w.NewPeep("red", Location{1, 1, 0})
+	peep2, _ := w.NewPeep("blue", Location{2, 2, 0})
+
+	Convey("Frame shows all alive peeps.", t, func() {
+		f := w.Frame()
+		So(len(f.Cells), ShouldEqual, 2)
+		So(f.Cells[0].ID, ShouldEqual, peep1.ID())
+		So(f.Cells[0].Icon, ShouldEqual, 'r')
+		So(f.Cells[1].ID, ShouldEqual, peep2.ID())
+		So(f.Homebases["red"], ShouldResemble, Location{3, 4, 0})
+	})
+
+	peep2.Die(w.turn)
+	Convey("Dead peeps flash for a few turns.", t, func() {
+		So(w.Frame().Cells[1].Dead, ShouldBeTrue)
+		w.turn += flashForXTurns + 1
+		So(len(w.Frame().Cells), ShouldEqual, 1)
+	})
+}
+
+func TestRenderer(t *testing.T) {
+	w := genWorld()
+	r := &recordingRenderer{}
+	w.SetRenderer(r)
+	w.NewPeep("red", Location{1, 1, 0})
+
+	Convey("Renderer draws every turn.", t, func() {
+		w.NextTurn()
+		w.NextTurn()
+		So(len(r.frames), ShouldEqual, 2)
+		So(r.frames[1].Turn, ShouldEqual, 2)
+		So(len(r.frames[1].Cells), ShouldEqual, 1)
+	})
+
+	Convey("nil renderer means no renderer.", t, func() {
+		w.SetRenderer(nil)
+		So(w.NextTurn(), ShouldBeNil)
+	})
+}
+
+func TestColors(t *testing.T) {
+	w := genWorld()
+	peep1, _ := w.NewPeep("red", NewLocation())
+
+	Convey("Peeps is ColorRed", t, func() {
+		So(w.ExisterFg(peep1), ShouldEqual, termbox.ColorRed)
+	})
+
+	Convey("Peeps is ColorRed", t, func() {
+		So(w.ExisterBg(peep1), ShouldEqual, termbox.ColorDefault)
+	})
+}
+
+func TestTermboxController(t *testing.T) {
+	w := genWorld()
+	c := NewTermboxController(w)
+
+	Convey("Enter steps one turn.", t, func() {
+		c.Handle(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEnter})
+		So(w.ControlInfo(), ShouldResemble, ControlInfo{State: StateStepping, Steps: 1})
+	})
+
+	Convey("P pauses and resumes.", t, func() {
+		c.Handle(termbox.Event{Type: termbox.EventKey, Ch: 'p'})
+		So(w.State(), ShouldEqual, StatePaused)
+		c.Handle(termbox.Event{Type: termbox.EventKey, Ch: 'p'})
+		So(w.State(), ShouldEqual, StateRunning)
+	})
+
+	Convey("Esc stops the world.", t, func() {
+		c.Handle(termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEsc})
+		So(w.State(), ShouldEqual, StateStopped)
+	})
+}
@@ -0,0 +1,226 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ReplayPeep is the state of a peep rebuilt from an event log
+type ReplayPeep struct {
+	ID         string          `json:"id"`
+	Gender     PeepGender      `json:"gender"`
+	Parents    []string        `json:"parents,omitempty"`
+	Age        PeepAge         `json:"age"`
+	Alive      bool            `json:"alive"`
+	Location   Location        `json:"location"`
+	DeadAtTurn Turn            `json:"dead_at_turn,omitempty"`
+	Cause      DeathCause      `json:"cause,omitempty"`
+	Met        map[string]Turn `json:"met,omitempty"` // other peep id -> last turn met
+}
+
+// ReplayPredator is the state of a predator rebuilt from an event log
+type ReplayPredator struct {
+	ID         string     `json:"id"`
+	Parents    []string   `json:"parents,omitempty"`
+	Age        PeepAge    `json:"age"`
+	Alive      bool       `json:"alive"`
+	Location   Location   `json:"location"`
+	BornAtTurn Turn       `json:"born_at_turn"`
+	DeadAtTurn Turn       `json:"dead_at_turn,omitempty"`
+	Cause      DeathCause `json:"cause,omitempty"`
+	Kills      int        `json:"kills,omitempty"` // peeps eaten
+}
+
+// ReplayState is the state of a world rebuilt from its event log, without running the simulation
+type ReplayState struct {
+	Turn      Turn
+	Peeps     map[string]*ReplayPeep
+	Predators map[string]*ReplayPredator
+}
+
+// NewReplayState returns the state of an empty world
+func NewReplayState() *ReplayState {
+	return &ReplayState{Peeps: make(map[string]*ReplayPeep), Predators: make(map[string]*ReplayPredator)}
+}
+
+// peep returns the peep with the given id, or an error if it was never born
+func (s *ReplayState) peep(id string) (*ReplayPeep, error) {
+	p, ok := s.Peeps[id]
+	if !ok {
+		return nil, fmt.Errorf("turn %v: event for unknown peep %v", s.Turn, id)
+	}
+	return p, nil
+}
+
+// predator returns the predator with the given id, or an error if it was never born
+func (s *ReplayState) predator(id string) (*ReplayPredator, error) {
+	p, ok := s.Predators[id]
+	if !ok {
+		return nil, fmt.Errorf("turn %v: event for unknown predator %v", s.Turn, id)
+	}
+	return p, nil
+}
+
+// Apply updates the state with a single event
+func (s *ReplayState) Apply(e Event) error {
+	switch e.Type {
+	case EventBirth:
+		if e.Location == nil {
+			return fmt.Errorf("turn %v: birth of %v without a location", e.Turn, e.ID)
+		}
+		s.Peeps[e.ID] = &ReplayPeep{
+			ID:       e.ID,
+			Gender:   e.Gender,
+			Parents:  e.Parents,
+			Age:      e.Age,
+			Alive:    true,
+			Location: *e.Location,
+			Met:      make(map[string]Turn),
+		}
+
+	case EventDeath:
+		p, err := s.peep(e.ID)
+		if err != nil {
+			return err
+		}
+		p.Alive = false
+		p.Age = e.Age
+		p.DeadAtTurn = e.Turn
+		p.Cause = e.Cause
+
+	case EventMove:
+		if e.Location == nil {
+			return fmt.Errorf("turn %v: move of %v without a location", e.Turn, e.ID)
+		}
+		if p, ok := s.Predators[e.ID]; ok {
+			p.Location = *e.Location
+			break
+		}
+		p, err := s.peep(e.ID)
+		if err != nil {
+			return err
+		}
+		p.Location = *e.Location
+
+	case EventPredatorBirth:
+		if e.Location == nil {
+			return fmt.Errorf("turn %v: birth of predator %v without a location", e.Turn, e.ID)
+		}
+		s.Predators[e.ID] = &ReplayPredator{
+			ID:         e.ID,
+			Parents:    e.Parents,
+			Age:        e.Age,
+			Alive:      true,
+			Location:   *e.Location,
+			BornAtTurn: e.Turn,
+		}
+
+	case EventPredatorDeath:
+		p, err := s.predator(e.ID)
+		if err != nil {
+			return err
+		}
+		p.Alive = false
+		p.Age = e.Age
+		p.DeadAtTurn = e.Turn
+		p.Cause = e.Cause
+
+	case EventKill:
+		p, err := s.predator(e.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := s.peep(e.Other); err != nil {
+			return err
+		}
+		p.Kills++ // the peep's death is its own event
+
+	case EventMeet:
+		left, err := s.peep(e.ID)
+		if err != nil {
+			return err
+		}
+		right, err := s.peep(e.Other)
+		if err != nil {
+			return err
+		}
+		left.Met[right.ID] = e.Turn
+		right.Met[left.ID] = e.Turn
+
+	case EventTurn:
+		for _, p := range s.Peeps {
+			if p.Alive {
+				p.Age++
+			}
+		}
+		for _, p := range s.Predators {
+			// predators spawn while the others age, so they only start aging on the next turn
+			if p.Alive && p.BornAtTurn < e.Turn {
+				p.Age++
+			}
+		}
+	}
+
+	s.Turn = e.Turn
+	return nil
+}
+
+// Alive returns all alive peeps, ordered by location
+func (s *ReplayState) Alive() []*ReplayPeep {
+	var alive []*ReplayPeep
+	for _, p := range s.Peeps {
+		if p.Alive {
+			alive = append(alive, p)
+		}
+	}
+	sort.Slice(alive, func(i, j int) bool {
+		return alive[i].Location.Less(alive[j].Location)
+	})
+	return alive
+}
+
+// AlivePredators returns all alive predators, ordered by location
+func (s *ReplayState) AlivePredators() []*ReplayPredator {
+	var alive []*ReplayPredator
+	for _, p := range s.Predators {
+		if p.Alive {
+			alive = append(alive, p)
+		}
+	}
+	sort.Slice(alive, func(i, j int) bool {
+		return alive[i].Location.Less(alive[j].Location)
+	})
+	return alive
+}
+
+// Replay reads a JSON event log (see JSONEventLog) and rebuilds the world as it was at the end of turn.
+// Turn 0 is the world before the first turn.
+func Replay(r io.Reader, turn Turn) (*ReplayState, error) {
+	s := NewReplayState()
+	decoder := json.NewDecoder(r)
+
+	for {
+		var e Event
+		if err := decoder.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("cannot read event log: %v", err)
+		}
+
+		if e.Turn > turn {
+			break
+		}
+		if err := s.Apply(e); err != nil {
+			return nil, err
+		}
+		if e.Type == EventTurn && e.Turn == turn {
+			break
+		}
+	}
+	if s.Turn < turn {
+		return nil, fmt.Errorf("event log ends at turn %v, before turn %v", s.Turn, turn)
+	}
+	return s, nil
+}
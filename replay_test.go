@@ -0,0 +1,155 @@
+package world
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// alivePeeps returns the alive peeps of a frame as replay peeps, without meetings
+func alivePeeps(f *Frame) []ReplayPeep {
+	var peeps []ReplayPeep
+	for _, c := range f.Cells {
+		if !c.Dead && !c.Predator {
+			peeps = append(peeps, ReplayPeep{ID: c.ID, Gender: c.Gender, Age: c.Age, Alive: true, Location: c.Location})
+		}
+	}
+	return peeps
+}
+
+// replayedPeeps returns the alive peeps of a replay state, without meetings
+func replayedPeeps(s *ReplayState) []ReplayPeep {
+	var peeps []ReplayPeep
+	for _, p := range s.Alive() {
+		peeps = append(peeps, ReplayPeep{ID: p.ID, Gender: p.Gender, Age: p.Age, Alive: true, Location: p.Location})
+	}
+	return peeps
+}
+
+func TestReplay(t *testing.T) {
+	enableMoves(t)
+
+	var log bytes.Buffer
+	w := genSeededWorld(42)
+	w.AddEventSink(NewJSONEventLog(&log))
+	w.settings.KillIfSurrounded = true
+
+	frames := []*Frame{w.Frame()}
+	for i := 0; i < 150; i++ {
+		w.NextTurn()
+		frames = append(frames, w.Frame())
+	}
+
+	Convey("Replaying the log rebuilds the world at any turn.", t, func() {
+		for _, turn := range []Turn{0, 1, 10, 75, 150} {
+			s, err := Replay(bytes.NewReader(log.Bytes()), turn)
+			So(err, ShouldBeNil)
+			So(s.Turn, ShouldEqual, turn)
+			So(replayedPeeps(s), ShouldResemble, alivePeeps(frames[turn]))
+		}
+	})
+
+	Convey("Turns past the end of the log are an error.", t, func() {
+		_, err := Replay(bytes.NewReader(log.Bytes()), 151)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Events for unknown peeps are an error.", t, func() {
+		_, err := Replay(strings.NewReader(`{"type": "move", "turn": 1, "id": "nobody", "location": {"x": 1, "y": 1, "z": 0}}`), 1)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+// alivePredators returns the alive predators of a frame as replay predators, with only what frames show
+func alivePredators(f *Frame) []ReplayPredator {
+	var predators []ReplayPredator
+	for _, c := range f.Cells {
+		if !c.Dead && c.Predator {
+			predators = append(predators, ReplayPredator{ID: c.ID, Age: c.Age, Alive: true, Location: c.Location})
+		}
+	}
+	return predators
+}
+
+// replayedPredators returns the alive predators of a replay state, with only what frames show
+func replayedPredators(s *ReplayState) []ReplayPredator {
+	var predators []ReplayPredator
+	for _, p := range s.AlivePredators() {
+		predators = append(predators, ReplayPredator{ID: p.ID, Age: p.Age, Alive: true, Location: p.Location})
+	}
+	return predators
+}
+
+func TestReplayPredators(t *testing.T) {
+	enableMoves(t)
+
+	var log bytes.Buffer
+	s := genPredatorWorld(3).settings
+	s.PredatorFeed = s.PredatorEnergy // one peep is enough to spawn
+	w := NewWorld("Hunted", s, false)
+	w.AddEventSink(NewJSONEventLog(&log))
+	w.SetDefaultHomebases()
+	w.PlacePredators()
+
+	frames := []*Frame{w.Frame()}
+	for i := 0; i < 100; i++ {
+		w.NextTurn()
+		frames = append(frames, w.Frame())
+	}
+
+	Convey("Predators are replayed with the peeps they eat.", t, func() {
+		for _, turn := range []Turn{0, 1, 25, 50, 100} {
+			s, err := Replay(bytes.NewReader(log.Bytes()), turn)
+			So(err, ShouldBeNil)
+			So(replayedPeeps(s), ShouldResemble, alivePeeps(frames[turn]))
+			So(replayedPredators(s), ShouldResemble, alivePredators(frames[turn]))
+		}
+
+		s, _ := Replay(bytes.NewReader(log.Bytes()), 100)
+		var kills int
+		for _, p := range s.Predators {
+			kills += p.Kills
+		}
+		So(kills, ShouldBeGreaterThan, 0)
+		So(len(s.Predators), ShouldBeGreaterThan, 3) // children were born, and aged like their parents
+	})
+}
+
+func TestReplayLoadedWorld(t *testing.T) {
+	enableMoves(t)
+
+	s := genPredatorWorld(5).settings
+	s.PredatorFeed = s.PredatorEnergy
+	original := NewWorld("Hunted", s, false)
+	original.SetDefaultHomebases()
+	original.PlacePredators()
+	turnHistory(original, 30)
+
+	var saved, log bytes.Buffer
+	original.Snapshot(&saved)
+	w, err := LoadWorld(bytes.NewReader(saved.Bytes()), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := NewJSONEventLog(&log)
+	w.RecordPopulation(sink)
+	w.AddEventSink(sink)
+
+	frames := map[Turn]*Frame{30: w.Frame()}
+	for i := 0; i < 50; i++ {
+		w.NextTurn()
+		frames[w.Turn()] = w.Frame()
+	}
+
+	Convey("Logs added to populated worlds start with everyone already in them.", t, func() {
+		for _, turn := range []Turn{30, 31, 55, 80} {
+			s, err := Replay(bytes.NewReader(log.Bytes()), turn)
+			So(err, ShouldBeNil)
+			So(s.Turn, ShouldEqual, turn)
+			So(replayedPeeps(s), ShouldResemble, alivePeeps(frames[turn]))
+			So(replayedPredators(s), ShouldResemble, alivePredators(frames[turn]))
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Scenario describes a world to create: its settings, terrain, homebases and initial peeps.
+// Settings.Predators predators are placed at random once the peeps are.
+type Scenario struct {
+	Name      string                  `json:"name" yaml:"name"`
+	Settings  Settings                `json:"settings" yaml:"settings"`
+	Terrain   string                  `json:"terrain,omitempty" yaml:"terrain,omitempty"` // text map, see ReadTerrainMap
+	Homebases map[PeepGender]Location `json:"homebases" yaml:"homebases"`                 // if empty, SetDefaultHomebases is used
+	Peeps     []ScenarioPeep          `json:"peeps" yaml:"peeps"`
+}
+
+// ScenarioPeep is a peep present when the world is created, either at Location or on its homebase
+type ScenarioPeep struct {
+	Gender     PeepGender `json:"gender" yaml:"gender"` // empty picks one at random
+	Location   *Location  `json:"location,omitempty" yaml:"location,omitempty"`
+	AtHomebase bool       `json:"at_homebase,omitempty" yaml:"at_homebase,omitempty"`
+	Age        PeepAge    `json:"age" yaml:"age"`
+}
+
+// ScenarioFormat is the encoding of a scenario file
+type ScenarioFormat string
+
+const (
+	ScenarioJSON ScenarioFormat = "json"
+	ScenarioYAML ScenarioFormat = "yaml"
+)
+
+// ReadScenario decodes a scenario in the given format.
+// Unknown fields are an error to catch typos.
+func ReadScenario(r io.Reader, format ScenarioFormat) (*Scenario, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &Scenario{}
+	switch format {
+	case ScenarioJSON:
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.DisallowUnknownFields()
+		err = d.Decode(sc)
+	case ScenarioYAML:
+		err = yaml.UnmarshalStrict(b, sc)
+	default:
+		return nil, fmt.Errorf("unknown scenario format: %v", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %v scenario: %v", format, err)
+	}
+	return sc, nil
+}
+
+// LoadScenarioFile reads a scenario file, the format is picked from the extension (.json, .yaml or .yml).
+func LoadScenarioFile(path string) (*Scenario, error) {
+	var format ScenarioFormat
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = ScenarioJSON
+	case ".yaml", ".yml":
+		format = ScenarioYAML
+	default:
+		return nil, fmt.Errorf("unknown scenario file extension: %v", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadScenario(f, format)
+}
+
+// insideGrid returns true if loc is inside the grid described by size, border excluded
+func insideGrid(size *Size, loc Location) bool {
+	return loc.X < size.MaxX && loc.X > size.MinX &&
+		loc.Y < size.MaxY && loc.Y > size.MinY &&
+		loc.Z <= size.MaxZ && loc.Z >= size.MinZ
+}
+
+// Validate checks the settings, homebases and peeps of the scenario.
+// All problems are returned together as a ValidationError.
+func (sc *Scenario) Validate() error {
+	var v ValidationError
+	if err := sc.Settings.Validate(); err != nil {
+		v = append(v, err.(ValidationError)...)
+	}
+	if v.err() != nil {
+		// homebases and peeps cannot be checked against broken settings
+		return v
+	}
+
+	valid := sc.Settings.genders()
+
+	// impassable cells, to check homebases and peeps against
+	walls := make(map[Location]Terrain)
+	cells, err := ReadTerrainMap(strings.NewReader(sc.Terrain), terrainOrigin(sc.Settings.Size))
+	if err != nil {
+		v.add("Terrain", "", "%v", err)
+	}
+	for _, c := range cells {
+		if !insideGrid(sc.Settings.Size, c.Location) {
+			v.add("Terrain", c.Location, "outside the grid")
+			break
+		}
+		if !c.Terrain.Passable() {
+			walls[c.Location] = c.Terrain
+		}
+	}
+
+	homebases := sc.Homebases
+	if len(homebases) == 0 {
+		homebases = defaultHomebases(sc.Settings)
+	}
+	var homebaseGenders []string
+	for gender := range homebases {
+		homebaseGenders = append(homebaseGenders, string(gender))
+	}
+	sort.Strings(homebaseGenders)
+
+	for _, g := range homebaseGenders {
+		gender, loc := PeepGender(g), homebases[PeepGender(g)]
+		field := fmt.Sprintf("Homebases[%v]", gender)
+		if !containsGender(valid, gender) {
+			v.add(field, loc, "unknown gender, expected one of %v", valid)
+		}
+		if !insideGrid(sc.Settings.Size, loc) {
+			v.add(field, loc, "outside the grid")
+		}
+		if t, ok := walls[loc]; ok {
+			v.add(field, loc, "on %v", t)
+		}
+	}
+
+	if int64(len(sc.Peeps)) > sc.Settings.MaxPeeps {
+		v.add("Peeps", len(sc.Peeps), "more than MaxPeeps (%v)", sc.Settings.MaxPeeps)
+	}
+
+	taken := make(map[Location]int)
+	for i, p := range sc.Peeps {
+		field := fmt.Sprintf("Peeps[%v]", i)
+		if p.Gender != "" && !containsGender(valid, p.Gender) {
+			v.add(field+".Gender", p.Gender, "unknown gender, expected one of %v", valid)
+		}
+		if p.Age < 0 || p.Age >= sc.Settings.MaxAge {
+			v.add(field+".Age", p.Age, "must be in [0, MaxAge)")
+		}
+		if p.AtHomebase {
+			if p.Location != nil {
+				v.add(field+".Location", *p.Location, "set on a peep placed at_homebase")
+			}
+			home, ok := homebases[p.Gender]
+			if !ok {
+				v.add(field+".AtHomebase", p.Gender, "no homebase for this gender")
+				continue
+			}
+			if other, ok := taken[home]; ok {
+				v.add(field+".AtHomebase", home, "homebase already taken by Peeps[%v]", other)
+			}
+			taken[home] = i
+			continue
+		}
+		if p.Location == nil {
+			v.add(field+".Location", nil, "missing, set it or at_homebase")
+			continue
+		}
+		l := *p.Location
+		if !insideGrid(sc.Settings.Size, l) {
+			v.add(field+".Location", l, "outside the grid")
+		}
+		if t, ok := walls[l]; ok {
+			v.add(field+".Location", l, "on %v", t)
+		}
+		if other, ok := taken[l]; ok {
+			v.add(field+".Location", l, "already taken by Peeps[%v]", other)
+		}
+		taken[l] = i
+	}
+
+	return v.err()
+}
+
+// NewWorld validates the scenario and creates the world it describes
+func (sc *Scenario) NewWorld(debug bool) (*World, error) {
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+
+	w := NewWorld(sc.Name, sc.Settings, debug)
+	if err := w.LoadTerrain(strings.NewReader(sc.Terrain)); err != nil {
+		return nil, err
+	}
+	if len(sc.Homebases) == 0 {
+		w.SetDefaultHomebases()
+	}
+	for gender, loc := range sc.Homebases {
+		w.SetHomebase(gender, loc)
+	}
+
+	for i, p := range sc.Peeps {
+		if _, err := w.spawnPeep(p.Gender, p.Location, p.Age, nil); err != nil {
+			return nil, fmt.Errorf("cannot create Peeps[%v]: %v", i, err)
+		}
+	}
+	if err := w.PlacePredators(); err != nil {
+		return nil, err
+	}
+	w.Publish()
+	return w, nil
+}
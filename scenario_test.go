@@ -0,0 +1,188 @@
+package world
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fieldErrors returns the names of the invalid fields in err
+func fieldErrors(err error) []string {
+	var fields []string
+	if v, ok := err.(ValidationError); ok {
+		for _, e := range v {
+			fields = append(fields, e.Field)
+		}
+	}
+	return fields
+}
+
+func TestLoadScenarioFile(t *testing.T) {
+	Convey("YAML and JSON examples describe the same scenario.", t, func() {
+		fromYAML, err := LoadScenarioFile("scenarios/example.yaml")
+		So(err, ShouldBeNil)
+		fromJSON, err := LoadScenarioFile("scenarios/example.json")
+		So(err, ShouldBeNil)
+
+		So(fromYAML, ShouldResemble, fromJSON)
+		So(fromYAML.Settings.TurnTime, ShouldEqual, 100*time.Millisecond)
+		So(fromYAML.Settings.Size.MinX, ShouldEqual, -40)
+		So(fromYAML.Homebases["red"], ShouldResemble, Location{39, -14, 0})
+		So(len(fromYAML.Peeps), ShouldEqual, 4)
+	})
+
+	Convey("Unknown extensions are rejected.", t, func() {
+		_, err := LoadScenarioFile("scenarios/example.txt")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestReadScenario(t *testing.T) {
+	Convey("Unknown fields are rejected.", t, func() {
+		_, err := ReadScenario(strings.NewReader(`{"settings": {"max_agee": 10}}`), ScenarioJSON)
+		So(err, ShouldNotBeNil)
+		_, err = ReadScenario(strings.NewReader("settings:\n  max_agee: 10\n"), ScenarioYAML)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("TurnTime can be given in nanoseconds.", t, func() {
+		sc, err := ReadScenario(strings.NewReader(`{"settings": {"turn_time": 1000}}`), ScenarioJSON)
+		So(err, ShouldBeNil)
+		So(sc.Settings.TurnTime, ShouldEqual, time.Microsecond)
+	})
+}
+
+func TestSettingsValidate(t *testing.T) {
+	Convey("Test settings are valid.", t, func() {
+		So(genWorld().settings.Validate(), ShouldBeNil)
+	})
+
+	Convey("All problems are reported.", t, func() {
+		s := genWorld().settings
+		s.NewPeep = 0.5
+		s.RandomDeath = 2
+		s.MaxGenders = 5
+		s.SpawnAge = s.MaxAge
+		s.Size = nil
+
+		So(fieldErrors(s.Validate()), ShouldResemble, []string{
+			"RandomDeath", "NewPeepModifier", "SpawnAge", "MaxGenders", "Size",
+		})
+	})
+
+	Convey("Movement policies must be known.", t, func() {
+		s := genWorld().settings
+		s.MovementPolicy = MovementLifecycle
+		s.ElderAge = s.MaxAge
+		So(s.Validate(), ShouldBeNil)
+
+		s.MovementPolicy = "teleport"
+		s.ElderAge = s.MaxAge + 1
+		So(fieldErrors(s.Validate()), ShouldResemble, []string{"MovementPolicy", "ElderAge"})
+	})
+
+	Convey("Predators need a lifespan, energy and prey to feed on.", t, func() {
+		s := genWorld().settings
+		s.Predators = 2
+		So(fieldErrors(s.Validate()), ShouldResemble, []string{"PredatorMaxAge", "PredatorEnergy", "PredatorFeed"})
+
+		s.PredatorMaxAge, s.PredatorEnergy, s.PredatorFeed = 10, 20, 5
+		So(s.Validate(), ShouldBeNil)
+	})
+
+	Convey("Size must leave room inside the border.", t, func() {
+		s := genWorld().settings
+		s.Size = &Size{MaxX: 1, MinX: 0, MaxY: 10, MinY: -10, MaxZ: -1, MinZ: 0}
+		So(fieldErrors(s.Validate()), ShouldResemble, []string{"Size.MaxX", "Size.MaxZ"})
+	})
+}
+
+func TestScenarioValidate(t *testing.T) {
+	sc := &Scenario{
+		Settings: genWorld().settings,
+		Homebases: map[PeepGender]Location{
+			"red":    {9, 9, 0},
+			"purple": {1, 1, 0},
+		},
+		Peeps: []ScenarioPeep{
+			{Gender: "red", Location: &Location{1, 2, 0}},
+			{Gender: "red", Location: &Location{1, 2, 0}},
+			{Gender: "blue", Location: &Location{10, 2, 0}, Age: 100},
+			{Gender: "blue"},
+			{Gender: "blue", Location: &Location{3, 3, 0}, AtHomebase: true},
+		},
+	}
+
+	Convey("Homebases and peeps are checked.", t, func() {
+		So(fieldErrors(sc.Validate()), ShouldResemble, []string{
+			"Homebases[purple]", "Peeps[1].Location", "Peeps[2].Age", "Peeps[2].Location", "Peeps[3].Location", "Peeps[4].Location", "Peeps[4].AtHomebase",
+		})
+		_, err := sc.NewWorld(false)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestScenarioPeepLocations(t *testing.T) {
+	sc := &Scenario{
+		Settings:  genWorld().settings,
+		Homebases: map[PeepGender]Location{"red": {9, 9, 0}},
+		Peeps: []ScenarioPeep{
+			{Gender: "red", Location: &Location{0, 0, 0}},
+			{Gender: "red", AtHomebase: true},
+		},
+	}
+
+	Convey("Homebases are taken like any other location.", t, func() {
+		taken := &Scenario{
+			Settings:  sc.Settings,
+			Homebases: map[PeepGender]Location{"red": {9, 9, 0}, "blue": {-9, -9, 0}},
+			Peeps: []ScenarioPeep{
+				{Gender: "red", AtHomebase: true},
+				{Gender: "red", AtHomebase: true},
+				{Gender: "blue", Location: &Location{-9, -9, 0}},
+				{Gender: "blue", AtHomebase: true},
+				{AtHomebase: true},
+				{Gender: "red", Location: &Location{9, 9, 0}},
+			},
+		}
+		So(fieldErrors(taken.Validate()), ShouldResemble, []string{
+			"Peeps[1].AtHomebase", "Peeps[3].AtHomebase", "Peeps[4].AtHomebase", "Peeps[5].Location",
+		})
+	})
+
+	Convey("Peeps are placed at the origin or on their homebase.", t, func() {
+		w, err := sc.NewWorld(false)
+		So(err, ShouldBeNil)
+		So(w.LocationExister(Location{0, 0, 0}), ShouldNotBeNil)
+		So(w.LocationExister(Location{9, 9, 0}), ShouldNotBeNil)
+	})
+
+	Convey("Peeps at the origin and on their homebase are read from files.", t, func() {
+		sc, err := ReadScenario(strings.NewReader(`
+peeps:
+  - {gender: red, location: {x: 0, y: 0}}
+  - {gender: red, at_homebase: true}
+`), ScenarioYAML)
+		So(err, ShouldBeNil)
+		So(sc.Peeps[0].Location, ShouldResemble, &Location{})
+		So(sc.Peeps[1].Location, ShouldBeNil)
+		So(sc.Peeps[1].AtHomebase, ShouldBeTrue)
+	})
+}
+
+func TestScenarioNewWorld(t *testing.T) {
+	sc, err := LoadScenarioFile("scenarios/example.yaml")
+
+	Convey("The world described by the scenario is created.", t, func() {
+		So(err, ShouldBeNil)
+		w, err := sc.NewWorld(false)
+		So(err, ShouldBeNil)
+		So(w.name, ShouldEqual, "example")
+		So(w.AlivePeepCount(), ShouldEqual, 4)
+		So(w.PeepMinAge(), ShouldEqual, 20)
+		So(w.homebase["blue"], ShouldResemble, Location{-39, 14, 0})
+		So(w.LocationExister(Location{30, -10, 0}).(Social).Gender(), ShouldEqual, "red")
+	})
+}
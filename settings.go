@@ -1,27 +1,284 @@
 package world
 
-import "time"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
 
 type Settings struct {
-	MaxAge   PeepAge // cannot live beyond this age
-	MaxPeeps int64   // Absolute max peeps
-	NewPeep  float64 // Chances a new peep is born [0-1]
+	MaxAge   PeepAge `json:"max_age" yaml:"max_age"`     // cannot live beyond this age
+	MaxPeeps int64   `json:"max_peeps" yaml:"max_peeps"` // Absolute max peeps
+	NewPeep  float64 `json:"new_peep" yaml:"new_peep"`   // Chances a new peep is born [0-1]
 
 	// The lower this number, the less chance a new peep will show up as the population grows
 	// At 1, new random peeps will almost never show up
-	NewPeepModifier        float64
-	NewPeepMax             int64         // When this many peeps exist, no new peeps are spawned from origin
-	RandomDeath            float64       // chances of a random death
-	Size                   *Size         // world size, one line is used as the border around
-	SpawnAge               PeepAge       // Minimum age to spaw
-	SpawnProbability       float64       // chances of two peeps that meet spawning a new one
-	TurnTime               time.Duration // How fast is each turn?
-	YoungHightlightAge     PeepAge       // Up to this age, peeps are highlighted in the GUI
-	PeepRememberTurns      Turn          // How many turns peeps remember their surroundings for
-	PeepViewDistance       int32         // how far they can see
-	PeepSpawnInterval      Turn          // How many turns to wait after a spawn before can spawn again
-	KillIfSurroundByOther  bool          // If surrounded completely by other genders, die
-	KillIfSurroundedBySame bool          // If surrounded completely by same genders, die
-	KillIfSurrounded       bool          // If surrounded completely, die
-	MaxGenders             int           // Max different genders.  1-4
+	NewPeepModifier        float64       `json:"new_peep_modifier" yaml:"new_peep_modifier"`
+	NewPeepMax             int64         `json:"new_peep_max" yaml:"new_peep_max"`                               // When this many peeps exist, no new peeps are spawned from origin
+	RandomDeath            float64       `json:"random_death" yaml:"random_death"`                               // chances of a random death
+	Size                   *Size         `json:"size" yaml:"size"`                                               // world size, one line is used as the border around
+	SpawnAge               PeepAge       `json:"spawn_age" yaml:"spawn_age"`                                     // Minimum age to spaw
+	SpawnProbability       float64       `json:"spawn_probability" yaml:"spawn_probability"`                     // chances of two peeps that meet spawning a new one
+	TurnTime               time.Duration `json:"turn_time" yaml:"turn_time"`                                     // How fast is each turn?
+	YoungHightlightAge     PeepAge       `json:"young_highlight_age" yaml:"young_highlight_age"`                 // Up to this age, peeps are highlighted in the GUI
+	PeepRememberTurns      Turn          `json:"peep_remember_turns" yaml:"peep_remember_turns"`                 // How many turns peeps remember their surroundings for
+	PeepViewDistance       int32         `json:"peep_view_distance" yaml:"peep_view_distance"`                   // how far they can see
+	PeepSpawnInterval      Turn          `json:"peep_spawn_interval" yaml:"peep_spawn_interval"`                 // How many turns to wait after a spawn before can spawn again
+	KillIfSurroundByOther  bool          `json:"kill_if_surrounded_by_other" yaml:"kill_if_surrounded_by_other"` // If surrounded completely by other genders, die
+	KillIfSurroundedBySame bool          `json:"kill_if_surrounded_by_same" yaml:"kill_if_surrounded_by_same"`   // If surrounded completely by same genders, die
+	KillIfSurrounded       bool          `json:"kill_if_surrounded" yaml:"kill_if_surrounded"`                   // If surrounded completely, die
+	MaxGenders             int           `json:"max_genders" yaml:"max_genders"`                                 // Max different genders.  1-4
+	Seed                   int64         `json:"seed" yaml:"seed"`                                               // Seed for all randomness; worlds with the same seed and settings play out identically. 0 picks one from the clock.
+
+	// How peeps move when there is no mate in sight, see MovementPolicy
+	MovementPolicy MovementPolicy `json:"movement_policy,omitempty" yaml:"movement_policy"`
+	// From this age peeps wander instead of heading home with MovementLifecycle. 0 means three quarters of their lifespan.
+	ElderAge PeepAge `json:"elder_age,omitempty" yaml:"elder_age"`
+
+	// How the edges of the grid connect, see Topology. Empty means TopologyBounded.
+	Topology Topology `json:"topology,omitempty" yaml:"topology"`
+
+	// Food and energy, see FoodPatch. With no food patches peeps don't need to eat.
+	FoodPatches  int     `json:"food_patches,omitempty" yaml:"food_patches"`   // how many patches are placed when the world is created
+	FoodMax      float64 `json:"food_max,omitempty" yaml:"food_max"`           // most food a patch holds
+	FoodRegrowth float64 `json:"food_regrowth,omitempty" yaml:"food_regrowth"` // food regrown by every patch each turn
+	MaxEnergy    float64 `json:"max_energy,omitempty" yaml:"max_energy"`       // energy peeps are born with, and the most they can have
+	MoveEnergy   float64 `json:"move_energy,omitempty" yaml:"move_energy"`     // energy spent on every move
+	TurnEnergy   float64 `json:"turn_energy,omitempty" yaml:"turn_energy"`     // energy spent every turn just living
+	EatAmount    float64 `json:"eat_amount,omitempty" yaml:"eat_amount"`       // most food a peep eats in one turn
+
+	// Chances of each gene of a newborn mutating, see Genome
+	MutationRate float64 `json:"mutation_rate,omitempty" yaml:"mutation_rate"`
+
+	// Predators hunt peeps, see Predator. With no predators nothing hunts.
+	Predators            int     `json:"predators,omitempty" yaml:"predators"`                           // how many predators PlacePredators places
+	MaxPredators         int64   `json:"max_predators,omitempty" yaml:"max_predators"`                   // predators stop spawning at this many, 0 means no limit
+	PredatorMaxAge       PeepAge `json:"predator_max_age,omitempty" yaml:"predator_max_age"`             // predators cannot live beyond this age
+	PredatorViewDistance int32   `json:"predator_view_distance,omitempty" yaml:"predator_view_distance"` // how far predators see prey
+	PredatorEnergy       float64 `json:"predator_energy,omitempty" yaml:"predator_energy"`               // most energy a predator has; born with half, spawns when full
+	PredatorHunger       float64 `json:"predator_hunger,omitempty" yaml:"predator_hunger"`               // energy a predator spends every turn
+	PredatorFeed         float64 `json:"predator_feed,omitempty" yaml:"predator_feed"`                   // energy a predator gets from every peep it eats
+}
+
+// MovementPolicy decides where peeps go when there is no mate in sight
+type MovementPolicy string
+
+const (
+	// MovementRandom makes peeps step in a random direction, this is the default
+	MovementRandom MovementPolicy = "random"
+	// MovementLifecycle makes young peeps move away from their homebase, adults return to it and elders wander
+	MovementLifecycle MovementPolicy = "lifecycle"
+)
+
+// elderAge returns the age from which e is an elder, see ElderAge
+func (w *World) elderAge(e Exister) PeepAge {
+	if w.settings.ElderAge > 0 {
+		return w.settings.ElderAge
+	}
+	return genome(e).Lifespan * 3 / 4
+}
+
+// settingsJSON is Settings without its methods, used to encode and decode it
+type settingsJSON Settings
+
+// MarshalJSON encodes settings with TurnTime as a duration string, e.g. "100ms"
+func (s Settings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		settingsJSON
+		TurnTime string `json:"turn_time"`
+	}{settingsJSON(s), s.TurnTime.String()})
+}
+
+// UnmarshalJSON decodes settings. TurnTime may be a duration string ("100ms") or nanoseconds.
+func (s *Settings) UnmarshalJSON(b []byte) error {
+	v := struct {
+		*settingsJSON
+		TurnTime json.RawMessage `json:"turn_time"`
+	}{settingsJSON: (*settingsJSON)(s)}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+	if len(v.TurnTime) == 0 {
+		return nil
+	}
+
+	var turnTime string
+	if err := json.Unmarshal(v.TurnTime, &turnTime); err == nil {
+		t, err := time.ParseDuration(turnTime)
+		if err != nil {
+			return fmt.Errorf("turn_time: %v", err)
+		}
+		s.TurnTime = t
+		return nil
+	}
+	return json.Unmarshal(v.TurnTime, (*int64)(&s.TurnTime))
+}
+
+// FieldError describes a single invalid setting
+type FieldError struct {
+	Field  string      // name of the offending field
+	Value  interface{} // its value
+	Reason string      // what is wrong with it
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%v=%v: %v", e.Field, e.Value, e.Reason)
+}
+
+// ValidationError lists all problems found while validating
+type ValidationError []*FieldError
+
+func (v ValidationError) Error() string {
+	var errs []string
+	for _, e := range v {
+		errs = append(errs, e.Error())
+	}
+	return fmt.Sprintf("invalid settings: %v", strings.Join(errs, "; "))
+}
+
+// add records a new problem
+func (v *ValidationError) add(field string, value interface{}, reason string, args ...interface{}) {
+	*v = append(*v, &FieldError{Field: field, Value: value, Reason: fmt.Sprintf(reason, args...)})
+}
+
+// err returns nil if there are no problems
+func (v ValidationError) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// Validate checks that a world can be created with these settings.
+// All problems are returned together as a ValidationError.
+func (s Settings) Validate() error {
+	var v ValidationError
+
+	probabilities := []struct {
+		field string
+		value float64
+	}{
+		{"NewPeep", s.NewPeep},
+		{"RandomDeath", s.RandomDeath},
+		{"SpawnProbability", s.SpawnProbability},
+		{"MutationRate", s.MutationRate},
+	}
+	for _, p := range probabilities {
+		if p.value < 0 || p.value > 1 {
+			v.add(p.field, p.value, "probability must be in [0, 1]")
+		}
+	}
+
+	if s.MaxAge <= 0 {
+		v.add("MaxAge", s.MaxAge, "must be positive")
+	}
+	if s.MaxPeeps < 0 {
+		v.add("MaxPeeps", s.MaxPeeps, "must not be negative")
+	}
+	if s.NewPeepMax < 0 {
+		v.add("NewPeepMax", s.NewPeepMax, "must not be negative")
+	}
+	if s.NewPeep > 0 && s.NewPeepModifier <= 0 {
+		v.add("NewPeepModifier", s.NewPeepModifier, "must be positive when NewPeep is set")
+	}
+	if s.SpawnAge < 0 || s.SpawnAge >= s.MaxAge {
+		v.add("SpawnAge", s.SpawnAge, "must be in [0, MaxAge), peeps would never spawn")
+	}
+	if s.TurnTime < 0 {
+		v.add("TurnTime", s.TurnTime, "must not be negative")
+	}
+	if s.YoungHightlightAge < 0 {
+		v.add("YoungHightlightAge", s.YoungHightlightAge, "must not be negative")
+	}
+	if s.PeepRememberTurns < 0 {
+		v.add("PeepRememberTurns", s.PeepRememberTurns, "must not be negative")
+	}
+	if s.PeepViewDistance < 0 {
+		v.add("PeepViewDistance", s.PeepViewDistance, "must not be negative")
+	}
+	if s.PeepSpawnInterval < 0 {
+		v.add("PeepSpawnInterval", s.PeepSpawnInterval, "must not be negative")
+	}
+	switch s.MovementPolicy {
+	case "", MovementRandom, MovementLifecycle:
+	default:
+		v.add("MovementPolicy", s.MovementPolicy, "must be %q or %q", MovementRandom, MovementLifecycle)
+	}
+	if s.ElderAge < 0 || s.ElderAge > s.MaxAge {
+		v.add("ElderAge", s.ElderAge, "must be in [0, MaxAge]")
+	}
+	switch s.Topology {
+	case "", TopologyBounded, TopologyTorus, TopologyCylinder:
+	default:
+		v.add("Topology", s.Topology, "must be %q, %q or %q", TopologyBounded, TopologyTorus, TopologyCylinder)
+	}
+	if s.FoodPatches < 0 {
+		v.add("FoodPatches", s.FoodPatches, "must not be negative")
+	}
+	food := []struct {
+		field string
+		value float64
+	}{
+		{"FoodMax", s.FoodMax},
+		{"FoodRegrowth", s.FoodRegrowth},
+		{"MaxEnergy", s.MaxEnergy},
+		{"MoveEnergy", s.MoveEnergy},
+		{"TurnEnergy", s.TurnEnergy},
+		{"EatAmount", s.EatAmount},
+	}
+	for _, f := range food {
+		if f.value < 0 {
+			v.add(f.field, f.value, "must not be negative")
+		} else if s.foodEnabled() && f.value == 0 && (f.field == "FoodMax" || f.field == "MaxEnergy" || f.field == "EatAmount") {
+			v.add(f.field, f.value, "must be positive when FoodPatches is set")
+		}
+	}
+	if s.Predators < 0 {
+		v.add("Predators", s.Predators, "must not be negative")
+	}
+	if s.MaxPredators < 0 {
+		v.add("MaxPredators", s.MaxPredators, "must not be negative")
+	}
+	if s.PredatorViewDistance < 0 {
+		v.add("PredatorViewDistance", s.PredatorViewDistance, "must not be negative")
+	}
+	if s.PredatorHunger < 0 {
+		v.add("PredatorHunger", s.PredatorHunger, "must not be negative")
+	}
+	if s.predatorsEnabled() {
+		if s.PredatorMaxAge <= 0 {
+			v.add("PredatorMaxAge", s.PredatorMaxAge, "must be positive when Predators is set")
+		}
+		if s.PredatorEnergy <= 0 {
+			v.add("PredatorEnergy", s.PredatorEnergy, "must be positive when Predators is set")
+		}
+		if s.PredatorFeed <= 0 {
+			v.add("PredatorFeed", s.PredatorFeed, "must be positive when Predators is set")
+		}
+	}
+	if s.MaxGenders < 1 || s.MaxGenders > len(genders) {
+		v.add("MaxGenders", s.MaxGenders, "must be in [1, %v]", len(genders))
+	}
+
+	if s.Size == nil {
+		v.add("Size", s.Size, "is required")
+	} else {
+		// one line on each side of X and Y is the border
+		if s.Size.MaxX-s.Size.MinX < 2 {
+			v.add("Size.MaxX", s.Size.MaxX, "must be at least MinX+2 (%v) to leave room inside the border", s.Size.MinX+2)
+		}
+		if s.Size.MaxY-s.Size.MinY < 2 {
+			v.add("Size.MaxY", s.Size.MaxY, "must be at least MinY+2 (%v) to leave room inside the border", s.Size.MinY+2)
+		}
+		if s.Size.MaxZ < s.Size.MinZ {
+			v.add("Size.MaxZ", s.Size.MaxZ, "must be at least MinZ (%v)", s.Size.MinZ)
+		}
+	}
+
+	return v.err()
 }
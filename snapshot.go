@@ -0,0 +1,373 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// snapshotVersion is the version of the snapshot format written by Snapshot.
+// Bump it when the format changes and teach LoadWorld to read the old one.
+//
+//	1: initial format
+//	2: adds the family tree
+//	3: adds the path each peep is following
+//	4: adds food and the energy of peeps
+//	5: adds terrain
+//	6: adds the genome of peeps
+//	7: adds predators
+//	8: the random source is reseeded every turn, adds the turn it was reseeded for
+//	9: adds the counters of the stats
+const snapshotVersion = 9
+
+// worldSnapshot is the on-disk format of a world.
+// Existers refer to each other by id.
+type worldSnapshot struct {
+	Version   int                     `json:"version"`
+	Name      string                  `json:"name"`
+	Turn      Turn                    `json:"turn"`
+	Settings  Settings                `json:"settings"`
+	Random    randomSnapshot          `json:"random"`
+	Homebases map[PeepGender]Location `json:"homebases"`
+	Grid      []gridSnapshot          `json:"grid"`                // location -> exister
+	Peeps     []peepSnapshot          `json:"peeps"`               // every peep on the grid or referenced by another one
+	Family    []Lineage               `json:"family"`              // since version 2
+	Food      []FoodPatch             `json:"food"`                // since version 4
+	Terrain   []TerrainCell           `json:"terrain,omitempty"`   // since version 5
+	Predators []predatorSnapshot      `json:"predators,omitempty"` // since version 7, like Peeps
+	Stats     *statsSnapshot          `json:"stats,omitempty"`     // since version 9
+}
+
+// randomSnapshot is the state of the world's random source
+type randomSnapshot struct {
+	Seed  int64  `json:"seed"`
+	Turn  Turn   `json:"turn"`  // since version 8, before the source was only seeded once
+	Draws uint64 `json:"draws"` // since the source was seeded for Turn
+}
+
+// gridSnapshot is one occupied cell of the grid
+type gridSnapshot struct {
+	Location Location `json:"location"`
+	ID       string   `json:"id"`
+}
+
+// neighborSnapshot is one neighbor a peep saw when it last looked around
+type neighborSnapshot struct {
+	Location Location `json:"location"`
+	ID       string   `json:"id"`
+}
+
+// peepSnapshot is the state of a single peep
+type peepSnapshot struct {
+	ID         string             `json:"id"`
+	Gender     PeepGender         `json:"gender"`
+	Age        PeepAge            `json:"age"`
+	Alive      bool               `json:"alive"`
+	DeadAtTurn Turn               `json:"dead_at_turn"`
+	Cause      DeathCause         `json:"cause,omitempty"`
+	SpawnTurn  Turn               `json:"spawn_turn"`
+	LookTurn   Turn               `json:"look_turn"`
+	Location   *Location          `json:"location,omitempty"` // nil if the peep is no longer on the grid, the grid is restored from worldSnapshot.Grid
+	Met        map[string]Turn    `json:"met"`
+	Neighbors  []neighborSnapshot `json:"neighbors"`
+	Path       *pathSnapshot      `json:"path,omitempty"` // since version 3
+	Energy     *float64           `json:"energy"`         // since version 4
+	Genome     *Genome            `json:"genome"`         // since version 6
+}
+
+// predatorSnapshot is the state of a single predator
+type predatorSnapshot struct {
+	ID         string             `json:"id"`
+	Age        PeepAge            `json:"age"`
+	Alive      bool               `json:"alive"`
+	DeadAtTurn Turn               `json:"dead_at_turn"`
+	Cause      DeathCause         `json:"cause,omitempty"`
+	SpawnTurn  Turn               `json:"spawn_turn"`
+	LookTurn   Turn               `json:"look_turn"`
+	Location   *Location          `json:"location,omitempty"` // nil if the predator is no longer on the grid
+	Neighbors  []neighborSnapshot `json:"neighbors"`
+	Path       *pathSnapshot      `json:"path,omitempty"`
+	Energy     float64            `json:"energy"`
+	Genome     Genome             `json:"genome"`
+}
+
+// pathSnapshot is what is left of the path a peep is following, see nextStepTo
+type pathSnapshot struct {
+	To    Location   `json:"to"`
+	Steps []Location `json:"steps"`
+}
+
+// Snapshot writes the complete state of the world to writer, so it can be resumed with LoadWorld.
+func (w *World) Snapshot(writer io.Writer) error {
+	s := worldSnapshot{
+		Version:   snapshotVersion,
+		Name:      w.name,
+		Turn:      w.turn,
+		Settings:  w.Settings(),
+		Random:    randomSnapshot{Seed: w.randomSource.seed, Turn: w.randomSource.turn, Draws: w.randomSource.draws},
+		Homebases: w.homebase,
+		Family:    w.FamilyTree(),
+		Food:      w.Food(),
+		Terrain:   w.Terrain(),
+		Stats:     w.stats.snapshot(),
+	}
+
+	locations := w.grid.Locations()
+
+	// Collect every peep and predator on the grid and every one they refer to, other kinds are not saved
+	peeps := make(map[string]*Peep)
+	predators := make(map[string]*Predator)
+	var collect func(e Exister)
+	collect = func(e Exister) {
+		switch p := e.(type) {
+		case *Peep:
+			if _, ok := peeps[p.ID()]; ok {
+				return
+			}
+			peeps[p.ID()] = p
+			for other := range p.met {
+				collect(other)
+			}
+		case *Predator:
+			if _, ok := predators[p.ID()]; ok {
+				return
+			}
+			predators[p.ID()] = p
+		default:
+			return
+		}
+		for _, other := range e.(Perceiver).NeighborsFromLook() {
+			collect(other)
+		}
+	}
+
+	for _, loc := range locations {
+		e := w.grid.At(loc)
+		if !isPeep(e) && !isPredator(e) {
+			continue
+		}
+		s.Grid = append(s.Grid, gridSnapshot{Location: loc, ID: e.ID()})
+		collect(e)
+	}
+
+	neighbors := func(e Perceiver) []neighborSnapshot {
+		var ns []neighborSnapshot
+		for loc, other := range e.NeighborsFromLook() {
+			ns = append(ns, neighborSnapshot{Location: loc, ID: other.ID()})
+		}
+		sort.Slice(ns, func(i, j int) bool {
+			return ns[i].Location.Less(ns[j].Location)
+		})
+		return ns
+	}
+	path := func(e Exister) *pathSnapshot {
+		if path, ok := w.paths.paths[e]; ok {
+			return &pathSnapshot{To: path.dst, Steps: path.steps}
+		}
+		return nil
+	}
+
+	for _, p := range peeps {
+		ps := peepSnapshot{
+			ID:         p.id,
+			Gender:     p.gender,
+			Age:        p.age,
+			Alive:      p.isalive,
+			DeadAtTurn: p.deadAtTurn,
+			Cause:      p.deathCause,
+			SpawnTurn:  p.spawnTurn,
+			LookTurn:   p.lookTurn,
+			Met:        make(map[string]Turn),
+			Neighbors:  neighbors(p),
+			Path:       path(p),
+			Energy:     &p.energy,
+			Genome:     &p.genome,
+		}
+		if loc, err := w.grid.LocationOf(p); err == nil {
+			ps.Location = &loc
+		}
+		for other, turn := range p.met {
+			ps.Met[other.ID()] = turn
+		}
+		s.Peeps = append(s.Peeps, ps)
+	}
+	sort.Slice(s.Peeps, func(i, j int) bool {
+		return s.Peeps[i].ID < s.Peeps[j].ID
+	})
+
+	for _, p := range predators {
+		ps := predatorSnapshot{
+			ID:         p.id,
+			Age:        p.age,
+			Alive:      p.isalive,
+			DeadAtTurn: p.deadAtTurn,
+			Cause:      p.deathCause,
+			SpawnTurn:  p.spawnTurn,
+			LookTurn:   p.lookTurn,
+			Neighbors:  neighbors(p),
+			Path:       path(p),
+			Energy:     p.energy,
+			Genome:     p.genome,
+		}
+		if loc, err := w.grid.LocationOf(p); err == nil {
+			ps.Location = &loc
+		}
+		s.Predators = append(s.Predators, ps)
+	}
+	sort.Slice(s.Predators, func(i, j int) bool {
+		return s.Predators[i].ID < s.Predators[j].ID
+	})
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// LoadWorld reads a world written by Snapshot.
+// The world continues exactly where it was stopped, including its random source; debug is as in NewWorld.
+func LoadWorld(reader io.Reader, debug bool) (*World, error) {
+	var s worldSnapshot
+	if err := json.NewDecoder(reader).Decode(&s); err != nil {
+		return nil, fmt.Errorf("cannot read snapshot: %v", err)
+	}
+	if s.Version < 1 || s.Version > snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %v, expected at most %v", s.Version, snapshotVersion)
+	}
+	if err := s.Settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	w := NewWorld(s.Name, s.Settings, debug)
+	w.turn = s.Turn
+	w.randomSource.restore(s.Random.Seed, s.Random.Turn, s.Random.Draws)
+	if s.Stats != nil {
+		w.stats.restore(s.Stats)
+	}
+	w.setTerrain(s.Terrain)
+	w.food = make(map[Location]*FoodPatch)
+	for _, f := range s.Food {
+		f := f
+		w.food[f.Location] = &f
+	}
+	for gender, loc := range s.Homebases {
+		w.SetHomebase(gender, loc)
+	}
+
+	peeps := make(map[string]*Peep)
+	for _, ps := range s.Peeps {
+		peeps[ps.ID] = &Peep{
+			id:         ps.ID,
+			age:        ps.Age,
+			isalive:    ps.Alive,
+			gender:     ps.Gender,
+			deadAtTurn: ps.DeadAtTurn,
+			deathCause: ps.Cause,
+			met:        make(map[Exister]Turn),
+			lookTurn:   ps.LookTurn,
+			world:      w,
+			neighbors:  make(map[Location]Exister),
+			spawnTurn:  ps.SpawnTurn,
+			energy:     s.Settings.MaxEnergy, // full if not recorded
+			genome:     s.Settings.defaultGenome(),
+		}
+		if ps.Energy != nil {
+			peeps[ps.ID].energy = *ps.Energy
+		}
+		if ps.Genome != nil {
+			peeps[ps.ID].genome = *ps.Genome
+		}
+	}
+
+	predators := make(map[string]*Predator)
+	for _, ps := range s.Predators {
+		predators[ps.ID] = &Predator{
+			id:         ps.ID,
+			age:        ps.Age,
+			isalive:    ps.Alive,
+			deadAtTurn: ps.DeadAtTurn,
+			deathCause: ps.Cause,
+			lookTurn:   ps.LookTurn,
+			spawnTurn:  ps.SpawnTurn,
+			energy:     ps.Energy,
+			genome:     ps.Genome,
+			world:      w,
+			neighbors:  make(map[Location]Exister),
+		}
+	}
+
+	lookup := func(id string) (Exister, error) {
+		if p, ok := peeps[id]; ok {
+			return p, nil
+		}
+		if p, ok := predators[id]; ok {
+			return p, nil
+		}
+		return nil, fmt.Errorf("snapshot refers to unknown exister %v", id)
+	}
+
+	for _, ps := range s.Peeps {
+		p := peeps[ps.ID]
+		for id, turn := range ps.Met {
+			other, err := lookup(id)
+			if err != nil {
+				return nil, err
+			}
+			p.met[other] = turn
+		}
+		for _, n := range ps.Neighbors {
+			other, err := lookup(n.ID)
+			if err != nil {
+				return nil, err
+			}
+			p.neighbors[n.Location] = other
+		}
+		if ps.Path != nil {
+			w.paths.set(p, cachedPath{dst: ps.Path.To, steps: ps.Path.Steps})
+		}
+	}
+	for _, ps := range s.Predators {
+		p := predators[ps.ID]
+		for _, n := range ps.Neighbors {
+			other, err := lookup(n.ID)
+			if err != nil {
+				return nil, err
+			}
+			p.neighbors[n.Location] = other
+		}
+		if ps.Path != nil {
+			w.paths.set(p, cachedPath{dst: ps.Path.To, steps: ps.Path.Steps})
+		}
+	}
+	for _, g := range s.Grid {
+		p, err := lookup(g.ID)
+		if err != nil {
+			return nil, err
+		}
+		w.grid.Set(p, g.Location)
+	}
+
+	for _, l := range s.Family {
+		l := l
+		w.family.add(&l)
+	}
+	if s.Version < 2 {
+		// Parents were not recorded, every known peep starts its own family
+		for _, ps := range s.Peeps {
+			born := s.Turn - Turn(ps.Age)
+			if !ps.Alive {
+				born = ps.DeadAtTurn - Turn(ps.Age)
+			}
+			w.family.add(&Lineage{
+				ID:        ps.ID,
+				Gender:    ps.Gender,
+				BirthTurn: born,
+				DeathTurn: ps.DeadAtTurn,
+				Cause:     ps.Cause,
+			})
+		}
+	}
+
+	w.Publish()
+	return w, nil
+}
@@ -0,0 +1,86 @@
+package world
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	enableMoves(t)
+
+	original := genSeededWorld(42)
+	turnHistory(original, 100)
+
+	var saved bytes.Buffer
+	err := original.Snapshot(&saved)
+
+	Convey("Snapshot is written.", t, func() {
+		So(err, ShouldBeNil)
+	})
+
+	restored, err := LoadWorld(bytes.NewReader(saved.Bytes()), false)
+
+	Convey("Loaded world is identical to the saved one.", t, func() {
+		So(err, ShouldBeNil)
+		So(restored.Turn(), ShouldEqual, 100)
+		So(restored.settings, ShouldResemble, original.settings)
+
+		var again bytes.Buffer
+		So(restored.Snapshot(&again), ShouldBeNil)
+		So(again.String(), ShouldEqual, saved.String())
+
+		debugged, err := LoadWorld(bytes.NewReader(saved.Bytes()), true)
+		So(err, ShouldBeNil)
+		So(debugged.debug, ShouldBeTrue)
+		So(restored.debug, ShouldBeFalse)
+	})
+
+	Convey("Stats count on from where they were.", t, func() {
+		So(original.stats.births.Count(), ShouldBeGreaterThan, 0)
+		So(original.stats.peepsDead.Value(), ShouldBeGreaterThan, 0)
+		So(restored.stats.snapshot(), ShouldResemble, original.stats.snapshot())
+		So(restored.stats.peepsDead.Value(), ShouldEqual, original.stats.peepsDead.Value())
+		So(restored.stats.deathAges.count, ShouldEqual, original.stats.deathAges.count)
+	})
+
+	Convey("Only the draws of the last turn are saved for the random source.", t, func() {
+		So(restored.randomSource.turn, ShouldEqual, 100)
+		So(restored.randomSource.draws, ShouldEqual, original.randomSource.draws)
+		So(restored.random.Int63(), ShouldEqual, original.random.Int63())
+
+		old := newRandomSource(42) // sources of snapshots before version 8 were only seeded once
+		for i := 0; i < 10; i++ {
+			old.Uint64()
+		}
+		again := newRandomSource(0)
+		again.restore(42, 0, 10)
+		So(again.Int63(), ShouldEqual, old.Int63())
+	})
+
+	Convey("Loaded world continues exactly like the saved one.", t, func() {
+		So(turnHistory(restored, 100), ShouldResemble, turnHistory(original, 100))
+	})
+}
+
+func TestLoadWorldErrors(t *testing.T) {
+	Convey("Garbage is rejected.", t, func() {
+		_, err := LoadWorld(strings.NewReader("not a world"), false)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Unknown versions are rejected.", t, func() {
+		_, err := LoadWorld(strings.NewReader(`{"version": 1000}`), false)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Dangling references are rejected.", t, func() {
+		var b bytes.Buffer
+		genSeededWorld(1).Snapshot(&b)
+		broken := strings.Replace(b.String(), `"grid": null`, `"grid": [{"location": {"x": 1, "y": 1, "z": 0}, "id": "nobody"}]`, 1)
+		_, err := LoadWorld(strings.NewReader(broken), false)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -1,33 +1,82 @@
 package world
 
 import (
-  "time"
-  "github.com/rcrowley/go-metrics"
-  "log"
-  "os"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
 )
 
+// deathAgeBuckets is the number of buckets in the age at death histogram
+const deathAgeBuckets = 10
 
 type stats struct {
+	registry   metrics.Registry
+	turn       metrics.Gauge
 	peepsAlive metrics.Gauge
-	peepsDead  metrics.Gauge
-	ages       metrics.Histogram
+	peepsDead  metrics.Gauge // peeps that died so far
+	predators  metrics.Gauge // alive predators
+	minAge     metrics.Gauge
+	avgAge     metrics.Gauge
+	maxAge     metrics.Gauge
+	births     metrics.Counter
+	ages       metrics.Histogram // sample of ages at death
+	turnTime   metrics.Timer
+	genders    map[PeepGender]metrics.Gauge   // alive peeps per gender
+	genome     []metrics.GaugeFloat64         // average of each gene of alive peeps, in the order of genes
+	deaths     map[DeathCause]metrics.Counter // deaths per cause
+	deathAges  *bucketHistogram
 }
 
-func newStats() *stats {
+// newStats returns stats with the age at death histogram spread up to maxAge, the oldest a peep can get
+func newStats(maxAge PeepAge) *stats {
 	r := metrics.NewRegistry()
 
 	stats := &stats{
+		registry:   r,
+		turn:       metrics.NewGauge(),
 		peepsAlive: metrics.NewGauge(),
 		peepsDead:  metrics.NewGauge(),
+		predators:  metrics.NewGauge(),
+		minAge:     metrics.NewGauge(),
+		avgAge:     metrics.NewGauge(),
+		maxAge:     metrics.NewGauge(),
+		births:     metrics.NewCounter(),
 		ages:       metrics.NewHistogram(metrics.NewUniformSample(1028)),
+		turnTime:   metrics.NewTimer(),
+		genders:    make(map[PeepGender]metrics.Gauge),
+		deaths:     make(map[DeathCause]metrics.Counter),
+		deathAges:  newBucketHistogram(maxAge, deathAgeBuckets),
 	}
 
+	r.Register("turn", stats.turn)
 	r.Register("peeps_alive", stats.peepsAlive)
 	r.Register("peeps_dead", stats.peepsDead)
+	r.Register("predators_alive", stats.predators)
+	r.Register("age_min", stats.minAge)
+	r.Register("age_avg", stats.avgAge)
+	r.Register("age_max", stats.maxAge)
+	r.Register("births", stats.births)
 	r.Register("ages", stats.ages)
+	r.Register("turn_time", stats.turnTime)
 
-	go metrics.Log(metrics.DefaultRegistry, time.Second*1, log.New(os.Stderr, "metrics: ", log.Lmicroseconds))
+	for _, gender := range genders {
+		stats.genders[gender] = metrics.NewGauge()
+		r.Register("peeps_alive_"+string(gender), stats.genders[gender])
+	}
+	for _, cause := range deathCauses {
+		stats.deaths[cause] = metrics.NewCounter()
+		r.Register("deaths_"+string(cause), stats.deaths[cause])
+	}
+	for _, gene := range genes {
+		g := metrics.NewGaugeFloat64()
+		stats.genome = append(stats.genome, g)
+		r.Register("genome_"+gene, g)
+	}
 
 	//go influxdb.Influxdb(r, time.Second*1, &influxdb.Config{
 	//	Host:     "127.0.0.1:8086",
@@ -38,3 +87,197 @@ func newStats() *stats {
 	return stats
 
 }
+
+// Record updates the counters from world events
+func (s *stats) Record(e Event) error {
+	switch e.Type {
+	case EventBirth:
+		s.births.Inc(1)
+	case EventDeath:
+		s.peepsDead.Update(s.peepsDead.Value() + 1)
+		s.ages.Update(int64(e.Age))
+		s.deathAges.observe(float64(e.Age))
+		if c, ok := s.deaths[e.Cause]; ok {
+			c.Inc(1)
+		} else {
+			s.deaths[DeathUnknown].Inc(1)
+		}
+	}
+	return nil
+}
+
+// statsSnapshot is what the stats added up over the whole run, saved so restored worlds count on, see Snapshot
+type statsSnapshot struct {
+	Births      int64                `json:"births"`
+	Deaths      map[DeathCause]int64 `json:"deaths"`
+	DeathAges   []uint64             `json:"death_ages"` // per bucket of the age at death histogram, not cumulative
+	DeathAgeSum float64              `json:"death_age_sum"`
+}
+
+// snapshot returns the counters of s
+func (s *stats) snapshot() *statsSnapshot {
+	s.deathAges.lock.Lock()
+	defer s.deathAges.lock.Unlock()
+
+	ss := &statsSnapshot{
+		Births:      s.births.Count(),
+		Deaths:      make(map[DeathCause]int64),
+		DeathAges:   append([]uint64(nil), s.deathAges.counts...),
+		DeathAgeSum: s.deathAges.sum,
+	}
+	for cause, c := range s.deaths {
+		ss.Deaths[cause] = c.Count()
+	}
+	return ss
+}
+
+// restore sets the counters of new stats to those of a snapshot
+func (s *stats) restore(ss *statsSnapshot) {
+	s.deathAges.lock.Lock()
+	defer s.deathAges.lock.Unlock()
+
+	s.births.Inc(ss.Births)
+	var dead int64
+	for cause, n := range ss.Deaths {
+		if c, ok := s.deaths[cause]; ok {
+			c.Inc(n)
+		} else {
+			s.deaths[DeathUnknown].Inc(n)
+		}
+		dead += n
+	}
+	s.peepsDead.Update(dead)
+
+	copy(s.deathAges.counts, ss.DeathAges)
+	s.deathAges.sum = ss.DeathAgeSum
+	s.deathAges.count = uint64(dead)
+}
+
+// update records the state of the world at the end of a turn
+func (s *stats) update(v *View) {
+	s.turn.Update(int64(v.Info.Turn))
+	s.peepsAlive.Update(v.Info.Alive)
+	s.predators.Update(v.Info.Predators)
+	s.minAge.Update(int64(v.Info.Ages.Min))
+	s.avgAge.Update(int64(v.Info.Ages.Avg))
+	s.maxAge.Update(int64(v.Info.Ages.Max))
+
+	for gender, g := range s.genders {
+		g.Update(v.Info.Genders[gender])
+	}
+	for i, avg := range v.Info.Genome.values() {
+		s.genome[i].Update(avg)
+	}
+}
+
+// LogMetrics logs all metrics to writer every interval, until the program exits
+func (w *World) LogMetrics(interval time.Duration, writer io.Writer) {
+	go metrics.Log(w.stats.registry, interval, log.New(writer, "metrics: ", log.Lmicroseconds))
+}
+
+// bucketHistogram is a histogram with fixed buckets, as Prometheus expects
+type bucketHistogram struct {
+	lock   sync.Mutex
+	bounds []float64 // upper bound of each bucket
+	counts []uint64  // observations per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// newBucketHistogram returns a histogram with n buckets evenly spread up to max
+func newBucketHistogram(max PeepAge, n int) *bucketHistogram {
+	h := &bucketHistogram{counts: make([]uint64, n)}
+	for i := 1; i <= n; i++ {
+		h.bounds = append(h.bounds, float64(max)*float64(i)/float64(n))
+	}
+	return h
+}
+
+func (h *bucketHistogram) observe(v float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.sum += v
+	h.count++
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+			return
+		}
+	}
+}
+
+// writePrometheus writes the histogram in the Prometheus text format
+func (h *bucketHistogram) writePrometheus(writer io.Writer, name, help string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	fmt.Fprintf(writer, "# HELP %v %v\n# TYPE %v histogram\n", name, help, name)
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		fmt.Fprintf(writer, "%v_bucket{le=\"%v\"} %v\n", name, bound, cumulative)
+	}
+	fmt.Fprintf(writer, "%v_bucket{le=\"+Inf\"} %v\n", name, h.count)
+	fmt.Fprintf(writer, "%v_sum %v\n", name, h.sum)
+	fmt.Fprintf(writer, "%v_count %v\n", name, h.count)
+}
+
+// writeMetric writes the help and type lines of a metric
+func writeMetric(writer io.Writer, name, kind, help string) {
+	fmt.Fprintf(writer, "# HELP %v %v\n# TYPE %v %v\n", name, help, name, kind)
+}
+
+// writePrometheus writes all metrics in the Prometheus text format
+func (s *stats) writePrometheus(writer io.Writer) {
+	writeMetric(writer, "world_turn", "gauge", "Current turn.")
+	fmt.Fprintf(writer, "world_turn %v\n", s.turn.Value())
+
+	writeMetric(writer, "world_peeps_alive", "gauge", "Alive peeps.")
+	fmt.Fprintf(writer, "world_peeps_alive %v\n", s.peepsAlive.Value())
+
+	writeMetric(writer, "world_peeps_dead", "gauge", "Peeps that died so far.")
+	fmt.Fprintf(writer, "world_peeps_dead %v\n", s.peepsDead.Value())
+
+	writeMetric(writer, "world_predators_alive", "gauge", "Alive predators.")
+	fmt.Fprintf(writer, "world_predators_alive %v\n", s.predators.Value())
+
+	writeMetric(writer, "world_peeps_alive_by_gender", "gauge", "Alive peeps per gender.")
+	for _, gender := range genders {
+		fmt.Fprintf(writer, "world_peeps_alive_by_gender{gender=%q} %v\n", gender, s.genders[gender].Value())
+	}
+
+	writeMetric(writer, "world_peep_age", "gauge", "Min, average and max age of alive peeps.")
+	fmt.Fprintf(writer, "world_peep_age{stat=\"min\"} %v\n", s.minAge.Value())
+	fmt.Fprintf(writer, "world_peep_age{stat=\"avg\"} %v\n", s.avgAge.Value())
+	fmt.Fprintf(writer, "world_peep_age{stat=\"max\"} %v\n", s.maxAge.Value())
+
+	writeMetric(writer, "world_genome", "gauge", "Average genes of alive peeps.")
+	for i, gene := range genes {
+		fmt.Fprintf(writer, "world_genome{gene=%q} %v\n", gene, s.genome[i].Value())
+	}
+
+	writeMetric(writer, "world_births_total", "counter", "Peeps born.")
+	fmt.Fprintf(writer, "world_births_total %v\n", s.births.Count())
+
+	writeMetric(writer, "world_deaths_total", "counter", "Peeps died, by cause.")
+	for _, cause := range deathCauses {
+		fmt.Fprintf(writer, "world_deaths_total{cause=%q} %v\n", cause, s.deaths[cause].Count())
+	}
+
+	s.deathAges.writePrometheus(writer, "world_death_age", "Age of peeps when they died.")
+
+	t := s.turnTime.Snapshot()
+	writeMetric(writer, "world_turn_duration_seconds", "summary", "Time spent computing a turn.")
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		fmt.Fprintf(writer, "world_turn_duration_seconds{quantile=\"%v\"} %v\n", q, t.Percentile(q)/float64(time.Second))
+	}
+	fmt.Fprintf(writer, "world_turn_duration_seconds_sum %v\n", float64(t.Sum())/float64(time.Second))
+	fmt.Fprintf(writer, "world_turn_duration_seconds_count %v\n", t.Count())
+}
+
+// MetricsHandler serves all metrics in the Prometheus text format
+func (w *World) MetricsHandler(writer http.ResponseWriter, r *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.stats.writePrometheus(writer)
+}
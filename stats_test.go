@@ -0,0 +1,65 @@
+package world
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMetrics(t *testing.T) {
+	w := genWorld()
+	w.NewPeep("red", Location{1, 1, 0})
+	w.NewPeep("red", Location{2, 1, 0})
+	p, _ := w.NewPeep("blue", Location{3, 1, 0})
+	p.age = 3
+	w.kill(p, DeathSurrounded)
+	w.stats.update(w.Publish())
+
+	recorder := httptest.NewRecorder()
+	w.Router().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+
+	Convey("/metrics is in the Prometheus text format.", t, func() {
+		So(recorder.Code, ShouldEqual, http.StatusOK)
+		So(body, ShouldContainSubstring, "# TYPE world_births_total counter\n")
+		So(body, ShouldContainSubstring, "# TYPE world_death_age histogram\n")
+	})
+
+	Convey("Populations are counted.", t, func() {
+		So(body, ShouldContainSubstring, "world_peeps_alive 2\n")
+		So(body, ShouldContainSubstring, "world_peeps_dead 1\n")
+		So(body, ShouldContainSubstring, "world_peeps_alive_by_gender{gender=\"red\"} 2\n")
+		So(body, ShouldContainSubstring, "world_peeps_alive_by_gender{gender=\"blue\"} 0\n")
+	})
+
+	Convey("Births and deaths by cause are counted.", t, func() {
+		So(body, ShouldContainSubstring, "world_births_total 3\n")
+		So(body, ShouldContainSubstring, "world_deaths_total{cause=\"surrounded\"} 1\n")
+		So(body, ShouldContainSubstring, "world_deaths_total{cause=\"old_age\"} 0\n")
+	})
+
+	Convey("Age at death buckets are cumulative, up to the longest lifespan mutations can reach.", t, func() {
+		So(body, ShouldContainSubstring, "world_death_age_bucket{le=\"2\"} 0\n")
+		So(body, ShouldContainSubstring, "world_death_age_bucket{le=\"4\"} 1\n")
+		So(body, ShouldContainSubstring, "world_death_age_bucket{le=\"20\"} 1\n")
+		So(body, ShouldContainSubstring, "world_death_age_bucket{le=\"+Inf\"} 1\n")
+		So(body, ShouldContainSubstring, "world_death_age_sum 3\n")
+	})
+
+	Convey("Genes of alive peeps are averaged.", t, func() {
+		So(body, ShouldContainSubstring, "# TYPE world_genome gauge\n")
+		So(body, ShouldContainSubstring, "world_genome{gene=\"view_distance\"} 2\n")
+		So(body, ShouldContainSubstring, "world_genome{gene=\"lifespan\"} 10\n")
+		So(body, ShouldContainSubstring, "world_genome{gene=\"speed\"} 1\n")
+	})
+
+	Convey("Turns are counted and timed.", t, func() {
+		w.NextTurn()
+		recorder := httptest.NewRecorder()
+		w.MetricsHandler(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		So(recorder.Body.String(), ShouldContainSubstring, "world_turn 1\n")
+		So(recorder.Body.String(), ShouldContainSubstring, "world_turn_duration_seconds_count 1\n")
+	})
+}
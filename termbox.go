@@ -0,0 +1,182 @@
+package world
+
+import (
+	"math"
+
+	termbox "github.com/nsf/termbox-go"
+)
+
+// TermboxRenderer draws the world in the terminal.
+// termbox must already be initialized by the caller.
+type TermboxRenderer struct{}
+
+// NewTermboxRenderer returns a new renderer drawing with termbox
+func NewTermboxRenderer() *TermboxRenderer {
+	return &TermboxRenderer{}
+}
+
+func colorToTermbox(c PeepGender) termbox.Attribute {
+	switch c {
+	case "blue":
+		return termbox.ColorBlue
+	case "red":
+		return termbox.ColorRed
+	case "green":
+		return termbox.ColorGreen
+	case "yellow":
+		return termbox.ColorYellow
+	}
+	return termbox.ColorDefault
+}
+
+// ExisterFg returns the correct foreground color for an Exister
+func (w *World) ExisterFg(e Exister) termbox.Attribute {
+	if s, ok := e.(Social); ok {
+		return colorToTermbox(s.Gender())
+	}
+	return termbox.ColorDefault
+}
+
+// ExisterBg returns the correct background color for an Exister
+func (w *World) ExisterBg(e Exister) termbox.Attribute {
+	// Young ones are highlighted in white < 10 years
+	if _, ok := e.(Living); ok && age(e) < w.settings.YoungHightlightAge {
+		return termbox.ColorWhite
+	}
+
+	return termbox.ColorDefault
+}
+
+// Visuals describe visual attributes for displaying an Exister
+type Visuals struct {
+	Char rune              // character displayed
+	Fg   termbox.Attribute // foreground color
+	Bg   termbox.Attribute // background color
+}
+
+// ExisterVisuals returns all the visuals for a given Exister
+func (w *World) ExisterVisuals(e Exister) *Visuals {
+	v := &Visuals{
+		Char: objectIcon,
+		Fg:   termbox.ColorDefault,
+		Bg:   termbox.ColorDefault,
+	}
+
+	v.Char = w.ExisterIcon(e)
+	v.Fg = w.ExisterFg(e)
+	v.Bg = w.ExisterBg(e)
+
+	return v
+}
+
+// toTermbox converts world coordinates to termbox coordinates
+func toTermbox(size Size, loc Location) (int, int) {
+	termX := int(loc.X) + int(math.Abs(float64(size.MinX)))
+	termY := int(loc.Y) + int(math.Abs(float64(size.MinY)))
+	return termX, termY
+}
+
+// cellVisuals returns the visuals for an occupied cell
+func cellVisuals(c Cell) *Visuals {
+	if c.Dead {
+		return &Visuals{
+			Char: '☠',
+			Fg:   termbox.ColorMagenta,
+			Bg:   termbox.ColorBlack,
+		}
+	}
+
+	if c.Predator {
+		return &Visuals{
+			Char: c.Icon,
+			Fg:   termbox.ColorWhite | termbox.AttrBold,
+			Bg:   termbox.ColorRed,
+		}
+	}
+
+	v := &Visuals{
+		Char: c.Icon,
+		Fg:   colorToTermbox(c.Gender),
+		Bg:   termbox.ColorDefault,
+	}
+	// Young ones are highlighted in white
+	if c.Young {
+		v.Bg = termbox.ColorWhite
+	}
+	return v
+}
+
+// terrainVisuals returns the visuals for a cell that isn't plain ground
+func terrainVisuals(t Terrain) *Visuals {
+	switch t {
+	case TerrainWall:
+		return &Visuals{Char: ' ', Fg: termbox.ColorDefault, Bg: termbox.ColorWhite}
+	case TerrainWater:
+		return &Visuals{Char: '~', Fg: termbox.ColorWhite, Bg: termbox.ColorBlue}
+	case TerrainRough:
+		return &Visuals{Char: ',', Fg: termbox.ColorYellow, Bg: termbox.ColorDefault}
+	}
+	return &Visuals{Char: ' ', Fg: termbox.ColorDefault, Bg: termbox.ColorDefault}
+}
+
+// Draw draws the frame on the terminal
+func (t *TermboxRenderer) Draw(f *Frame) {
+	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+	t.drawGrid(f)
+
+	for _, c := range f.Terrain {
+		termX, termY := toTermbox(f.Size, c.Location)
+		visuals := terrainVisuals(c.Terrain)
+		termbox.SetCell(termX, termY, visuals.Char, visuals.Fg, visuals.Bg)
+	}
+
+	for _, c := range f.Cells {
+		termX, termY := toTermbox(f.Size, c.Location)
+		visuals := cellVisuals(c)
+		termbox.SetCell(termX, termY, visuals.Char, visuals.Fg, visuals.Bg)
+	}
+	termbox.Flush()
+}
+
+// borderVisuals returns the visuals for the border, dotted where the edge joins the opposite one (see Topology)
+func borderVisuals(wraps bool) *Visuals {
+	if wraps {
+		return &Visuals{Char: '·', Fg: termbox.Attribute(255), Bg: termbox.ColorDefault}
+	}
+	return &Visuals{Char: ' ', Fg: termbox.ColorDefault, Bg: termbox.Attribute(255)}
+}
+
+// drawGrid draws borders around the world and spawn points
+func (t *TermboxRenderer) drawGrid(f *Frame) {
+	width, height := termbox.Size()
+	horizontal, vertical := borderVisuals(f.Topology.wrapsY()), borderVisuals(f.Topology.wrapsX())
+
+	// Origin
+	termbox.SetCell(0, 0, ' ', termbox.ColorYellow, termbox.ColorYellow)
+
+	// top line
+	for x := 0; x <= width-2; x++ {
+		termbox.SetCell(x, 0, horizontal.Char, horizontal.Fg, horizontal.Bg)
+	}
+
+	// bottom line
+	for x := 0; x <= width-2; x++ {
+		termbox.SetCell(x, height-3, horizontal.Char, horizontal.Fg, horizontal.Bg)
+	}
+
+	// left border
+	for y := 0; y <= height-3; y++ {
+		termbox.SetCell(0, y, vertical.Char, vertical.Fg, vertical.Bg)
+	}
+
+	// right border
+	for y := 0; y <= height-3; y++ {
+		termbox.SetCell(width-2, y, vertical.Char, vertical.Fg, vertical.Bg)
+	}
+
+	// Homebases
+	for gender, loc := range f.Homebases {
+		termX, termY := toTermbox(f.Size, loc)
+		termbox.SetCell(termX, termY, ' ', colorToTermbox(gender), colorToTermbox(gender))
+	}
+}
@@ -0,0 +1,208 @@
+package world
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Terrain is what the ground of a cell is made of
+type Terrain string
+
+const (
+	TerrainGround Terrain = "ground"
+	TerrainWall   Terrain = "wall"  // nothing walks through walls
+	TerrainWater  Terrain = "water" // peeps don't swim
+	TerrainRough  Terrain = "rough" // walkable, but crossing it costs roughCost steps
+)
+
+// roughCost is what stepping on rough ground costs, in energy and in path length, compared to plain ground
+const roughCost = 3
+
+// terrainSymbols are the characters of a text terrain map
+var terrainSymbols = map[rune]Terrain{
+	'.': TerrainGround,
+	' ': TerrainGround,
+	'#': TerrainWall,
+	'~': TerrainWater,
+	',': TerrainRough,
+}
+
+// Passable returns true if existers can stand on t
+func (t Terrain) Passable() bool {
+	return t != TerrainWall && t != TerrainWater
+}
+
+// Cost returns what stepping on t costs compared to plain ground
+func (t Terrain) Cost() int32 {
+	if t == TerrainRough {
+		return roughCost
+	}
+	return 1
+}
+
+// TerrainCell is a cell that isn't plain ground
+type TerrainCell struct {
+	Location Location `json:"location"`
+	Terrain  Terrain  `json:"terrain"`
+}
+
+// ReadTerrainMap reads a text map, one line per row of cells and one character per cell:
+// '.' or ' ' is ground, '#' a wall, '~' water and ',' rough ground.
+// The first character of the first line is at origin, X grows to the right and Y downwards.
+// Only the cells that aren't ground are returned, ordered by location.
+func ReadTerrainMap(r io.Reader, origin Location) ([]TerrainCell, error) {
+	var cells []TerrainCell
+
+	scanner := bufio.NewScanner(r)
+	for row := int32(0); scanner.Scan(); row++ {
+		col := int32(0)
+		for _, c := range scanner.Text() {
+			t, ok := terrainSymbols[c]
+			if !ok {
+				return nil, fmt.Errorf("unknown terrain %q at line %v, column %v", c, row+1, col+1)
+			}
+			if t != TerrainGround {
+				cells = append(cells, TerrainCell{Location{origin.X + col, origin.Y + row, origin.Z}, t})
+			}
+			col++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read terrain map: %v", err)
+	}
+	return cells, nil
+}
+
+// terrainOrigin returns where the first character of a terrain map goes: the top left cell inside the border
+func terrainOrigin(size *Size) Location {
+	return Location{size.MinX + 1, size.MinY + 1, 0}
+}
+
+// LoadTerrain replaces the terrain of the world with the text map read from r, see ReadTerrainMap.
+// The map starts at the top left cell inside the border and must fit in the world.
+// Homebases and living existers cannot be walled in; food on cells that became impassable is placed elsewhere.
+func (w *World) LoadTerrain(r io.Reader) error {
+	cells, err := ReadTerrainMap(r, terrainOrigin(w.settings.Size))
+	if err != nil {
+		return err
+	}
+	for _, c := range cells {
+		if w.IsOutsideGrid(c.Location.X, c.Location.Y, c.Location.Z) {
+			return fmt.Errorf("terrain map is larger than the world, %v is outside the grid", c.Location)
+		}
+		if err := w.checkTerrain(c); err != nil {
+			return err
+		}
+	}
+
+	w.setTerrain(cells)
+	for l := range w.food {
+		if !w.TerrainAt(l).Passable() {
+			delete(w.food, l)
+		}
+	}
+	if w.settings.foodEnabled() {
+		w.placeFood()
+	}
+	return nil
+}
+
+// LoadTerrainFile reads the terrain of the world from a text map file, see LoadTerrain
+func (w *World) LoadTerrainFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return w.LoadTerrain(f)
+}
+
+// setTerrain replaces the terrain of the world.
+// The terrain is never changed in place, so frames can share it.
+func (w *World) setTerrain(cells []TerrainCell) {
+	w.terrain = make(map[Location]Terrain, len(cells))
+	w.terrainCells = nil
+	for _, c := range cells {
+		if c.Terrain == TerrainGround {
+			continue
+		}
+		w.terrain[c.Location] = c.Terrain
+		w.terrainCells = append(w.terrainCells, c)
+	}
+	sort.Slice(w.terrainCells, func(i, j int) bool {
+		return w.terrainCells[i].Location.Less(w.terrainCells[j].Location)
+	})
+	// neighbors depend on what is passable
+	w.locationNeighbors = make(map[neighborViewDistanceCache][]Location)
+}
+
+// checkTerrain returns an error if impassable terrain would cover a homebase or something standing on the cell
+func (w *World) checkTerrain(c TerrainCell) error {
+	if c.Terrain.Passable() {
+		return nil
+	}
+	for gender, l := range w.homebase {
+		if l == c.Location {
+			return fmt.Errorf("cannot put %v on the %v homebase at %v", c.Terrain, gender, l)
+		}
+	}
+	if w.IsOccupiedLocation(c.Location) {
+		return fmt.Errorf("cannot put %v on %v, it is occupied", c.Terrain, c.Location)
+	}
+	return nil
+}
+
+// SetTerrain changes the terrain of a single cell.
+// Like LoadTerrain it won't cover homebases or what stands on the cell, and it won't cover food either.
+func (w *World) SetTerrain(l Location, t Terrain) error {
+	if w.IsOutsideGrid(l.X, l.Y, l.Z) {
+		return fmt.Errorf("Location %v is outside the grid", l)
+	}
+	if err := w.checkTerrain(TerrainCell{l, t}); err != nil {
+		return err
+	}
+	if _, ok := w.food[l]; ok && !t.Passable() {
+		return fmt.Errorf("cannot put %v on %v, it has food", t, l)
+	}
+	cells := make([]TerrainCell, 0, len(w.terrainCells)+1)
+	for _, c := range w.terrainCells {
+		if c.Location != l {
+			cells = append(cells, c)
+		}
+	}
+	w.setTerrain(append(cells, TerrainCell{l, t}))
+	return nil
+}
+
+// TerrainAt returns the terrain of the cell at l
+func (w *World) TerrainAt(l Location) Terrain {
+	if t, ok := w.terrain[l]; ok {
+		return t
+	}
+	return TerrainGround
+}
+
+// Terrain returns every cell that isn't plain ground, ordered by location.
+// The slice is shared, it must not be changed.
+func (w *World) Terrain() []TerrainCell {
+	return w.terrainCells
+}
+
+// hasWalls returns true if some cells inside the grid are impassable
+func (w *World) hasWalls() bool {
+	for _, c := range w.terrainCells {
+		if !c.Terrain.Passable() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBlocked returns true if nothing can stand at the coordinates: they are outside the grid or impassable
+func (w *World) IsBlocked(x, y, z int32) bool {
+	return w.IsOutsideGrid(x, y, z) || !w.TerrainAt(Location{x, y, z}).Passable()
+}
@@ -0,0 +1,198 @@
+package world
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadTerrainMap(t *testing.T) {
+	Convey("Text maps are read from the origin, X to the right and Y down.", t, func() {
+		cells, err := ReadTerrainMap(strings.NewReader("#.~\n\n ,"), Location{-9, -9, 0})
+		So(err, ShouldBeNil)
+		So(cells, ShouldResemble, []TerrainCell{
+			{Location{-9, -9, 0}, TerrainWall},
+			{Location{-7, -9, 0}, TerrainWater},
+			{Location{-8, -7, 0}, TerrainRough},
+		})
+	})
+
+	Convey("Unknown characters are rejected.", t, func() {
+		_, err := ReadTerrainMap(strings.NewReader("..\n.x"), Location{})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "line 2, column 2")
+	})
+}
+
+func TestLoadTerrain(t *testing.T) {
+	w := genWorld()
+	w.SetHomebase("red", Location{-9, -9, 0})
+
+	Convey("Maps larger than the world are rejected.", t, func() {
+		So(w.LoadTerrain(strings.NewReader(strings.Repeat(".", 19)+"#")), ShouldNotBeNil)
+	})
+
+	Convey("Homebases and peeps cannot be walled in.", t, func() {
+		So(w.LoadTerrain(strings.NewReader("#")), ShouldNotBeNil)
+		w.NewPeep("red", Location{-8, -9, 0})
+		So(w.LoadTerrain(strings.NewReader(".~")), ShouldNotBeNil)
+		So(w.LoadTerrain(strings.NewReader(".,")), ShouldBeNil)
+		So(w.TerrainAt(Location{-8, -9, 0}), ShouldEqual, TerrainRough)
+	})
+
+	Convey("Food is moved off impassable cells.", t, func() {
+		w.settings.FoodPatches = 1
+		w.settings.FoodMax = 5
+		w.SetFood(Location{-7, -9, 0}, 5)
+		So(w.LoadTerrain(strings.NewReader("..#")), ShouldBeNil)
+		food := w.Food()
+		So(len(food), ShouldEqual, 1)
+		So(food[0].Location, ShouldNotResemble, Location{-7, -9, 0})
+		So(w.SetFood(Location{-7, -9, 0}, 5), ShouldNotBeNil)
+	})
+}
+
+func TestSetTerrain(t *testing.T) {
+	w := genWorld()
+	w.SetHomebase("red", Location{-9, -9, 0})
+	w.NewPeep("red", Location{1, 1, 0})
+	w.NewPredator(Location{2, 2, 0})
+	w.settings.FoodMax = 5
+	w.SetFood(Location{3, 3, 0}, 5)
+
+	Convey("Homebases cannot be walled in.", t, func() {
+		So(w.SetTerrain(Location{-9, -9, 0}, TerrainWall), ShouldNotBeNil)
+		So(w.TerrainAt(Location{-9, -9, 0}), ShouldEqual, TerrainGround)
+	})
+
+	Convey("Peeps and predators cannot be walled in.", t, func() {
+		So(w.SetTerrain(Location{1, 1, 0}, TerrainWater), ShouldNotBeNil)
+		So(w.SetTerrain(Location{2, 2, 0}, TerrainWall), ShouldNotBeNil)
+		So(w.SetTerrain(Location{1, 1, 0}, TerrainRough), ShouldBeNil)
+	})
+
+	Convey("Food cannot be covered.", t, func() {
+		So(w.Food(), ShouldHaveLength, 1)
+		So(w.SetTerrain(Location{3, 3, 0}, TerrainWall), ShouldNotBeNil)
+		So(w.SetTerrain(Location{3, 3, 0}, TerrainRough), ShouldBeNil)
+		So(w.SetTerrain(Location{4, 4, 0}, TerrainWall), ShouldBeNil)
+	})
+}
+
+func TestTerrainMovement(t *testing.T) {
+	w := genWorld()
+	w.settings.FoodPatches = 1
+	w.settings.MaxEnergy = 10
+	w.settings.MoveEnergy = 1
+	w.LoadTerrain(strings.NewReader(strings.Repeat("\n", 8) + ".........#~,")) // (0, -1) wall, (1, -1) water, (2, -1) rough
+	p, _ := w.NewPeep("red", Location{1, 0, 0})
+
+	Convey("Walls and water cannot be entered.", t, func() {
+		So(w.Move(p, -1, -1, 0), ShouldNotBeNil)
+		So(w.Move(p, 0, -1, 0), ShouldNotBeNil)
+		So(p.Location(), ShouldResemble, Location{1, 0, 0})
+
+		_, err := w.NewPeep("blue", Location{0, -1, 0})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Rough ground costs more energy to step on.", t, func() {
+		So(w.Move(p, 1, -1, 0), ShouldBeNil)
+		So(p.Energy(), ShouldEqual, 10-roughCost)
+		So(w.Move(p, 0, 1, 0), ShouldBeNil)
+		So(p.Energy(), ShouldEqual, 9-roughCost)
+	})
+
+	Convey("Impassable cells are not neighbors, and nothing is spawned on them.", t, func() {
+		neighbors := w.LocationNeighbors(Location{1, 0, 0}, 1)
+		So(len(neighbors), ShouldEqual, 6)
+		So(ListContains(neighbors, Location{0, -1, 0}), ShouldBeFalse)
+		So(ListContains(neighbors, Location{1, -1, 0}), ShouldBeFalse)
+		So(ListContains(neighbors, Location{2, -1, 0}), ShouldBeTrue)
+		So(w.totalNeighbors(Location{1, 0, 0}, 1), ShouldEqual, 6)
+
+		l, err := w.FindEmptyLocation(Location{0, -2, 0})
+		So(err, ShouldBeNil)
+		So(w.IsBlocked(l.X, l.Y, l.Z), ShouldBeFalse)
+	})
+}
+
+func TestTerrainPaths(t *testing.T) {
+	Convey("Paths find their way out of a maze.", t, func() {
+		w := genWorld()
+		for y := int32(-9); y < 9; y++ {
+			w.SetTerrain(Location{0, y, 0}, TerrainWall)
+		}
+		p, _ := w.NewPeep("red", Location{-3, 0, 0})
+
+		path, err := w.FindPath(p, Location{3, 0, 0})
+		So(err, ShouldBeNil)
+		So(len(path), ShouldEqual, 18)
+		So(ListContains(path, Location{0, 9, 0}), ShouldBeTrue)
+		So(path[len(path)-1], ShouldResemble, Location{3, 0, 0})
+
+		_, err = w.FindPath(p, Location{0, 0, 0})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Paths go around rough ground when it is cheaper.", t, func() {
+		w := genWorld()
+		for y := int32(4); y <= 6; y++ {
+			w.SetTerrain(Location{0, y, 0}, TerrainRough)
+		}
+		p, _ := w.NewPeep("red", Location{-2, 5, 0})
+
+		path, err := w.FindPath(p, Location{2, 5, 0})
+		So(err, ShouldBeNil)
+		So(path, ShouldResemble, []Location{{-1, 4, 0}, {0, 3, 0}, {1, 4, 0}, {2, 5, 0}})
+	})
+}
+
+func TestTerrainScenario(t *testing.T) {
+	sc, err := LoadScenarioFile("scenarios/island.yaml")
+
+	Convey("Scenarios carry their terrain.", t, func() {
+		So(err, ShouldBeNil)
+		w, err := sc.NewWorld(false)
+		So(err, ShouldBeNil)
+		So(w.TerrainAt(Location{0, -5, 0}), ShouldEqual, TerrainWall)
+		So(w.TerrainAt(Location{0, 0, 0}), ShouldEqual, TerrainGround)
+		So(w.TerrainAt(Location{-39, -14, 0}), ShouldEqual, TerrainWater)
+		So(w.Frame().Terrain, ShouldResemble, w.Terrain())
+	})
+
+	Convey("Homebases and peeps on impassable terrain are rejected.", t, func() {
+		sc.Homebases["blue"] = Location{-39, -14, 0}
+		sc.Peeps[0].Location = &Location{0, -5, 0}
+		err := sc.Validate()
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "Homebases[blue]")
+		So(err.Error(), ShouldContainSubstring, "Peeps[0].Location")
+
+		sc.Homebases = nil
+		sc.Terrain = strings.Repeat("\n", 28) + "#" // the default blue homebase
+		So(sc.Validate().Error(), ShouldContainSubstring, "Homebases[")
+	})
+}
+
+func TestTerrainSnapshot(t *testing.T) {
+	enableMoves(t)
+
+	original := genSeededWorld(11)
+	for y := int32(-9); y < 5; y++ {
+		original.SetTerrain(Location{2, y, 0}, TerrainWall)
+		original.SetTerrain(Location{-2, -y, 0}, TerrainRough)
+	}
+	turnHistory(original, 50)
+
+	Convey("Terrain is saved and the world continues like the original.", t, func() {
+		var saved bytes.Buffer
+		So(original.Snapshot(&saved), ShouldBeNil)
+		restored, err := LoadWorld(bytes.NewReader(saved.Bytes()), false)
+		So(err, ShouldBeNil)
+		So(restored.Terrain(), ShouldResemble, original.Terrain())
+		So(turnHistory(restored, 50), ShouldResemble, turnHistory(original, 50))
+	})
+}
@@ -0,0 +1,127 @@
+package world
+
+// Topology is how the edges of the grid connect, see Settings.Topology
+type Topology string
+
+const (
+	// TopologyBounded stops everything at the border, this is the default
+	TopologyBounded Topology = "bounded"
+	// TopologyTorus joins the left edge to the right one and the top edge to the bottom one
+	TopologyTorus Topology = "torus"
+	// TopologyCylinder joins the left edge to the right one, the top and bottom stop everything
+	TopologyCylinder Topology = "cylinder"
+)
+
+// wrapsX returns true if leaving the grid on the left or right comes back on the other side
+func (t Topology) wrapsX() bool {
+	return t == TopologyTorus || t == TopologyCylinder
+}
+
+// wrapsY returns true if leaving the grid at the top or bottom comes back on the other side
+func (t Topology) wrapsY() bool {
+	return t == TopologyTorus
+}
+
+// wrapsX returns true if leaving the grid on the left or right comes back on the other side, see Topology
+func (s Settings) wrapsX() bool {
+	return s.Topology.wrapsX()
+}
+
+// wrapsY returns true if leaving the grid at the top or bottom comes back on the other side, see Topology
+func (s Settings) wrapsY() bool {
+	return s.Topology.wrapsY()
+}
+
+// wrapAxis returns v moved into [min, max] by whole turns around the axis
+func wrapAxis(v, min, max int32) int32 {
+	n := max - min + 1
+	return min + ((v-min)%n+n)%n
+}
+
+// offsetAxis returns the shortest signed distance from a to b on an axis of n cells that wraps around
+func offsetAxis(a, b, n int32) int32 {
+	d := ((b-a)%n + n) % n
+	if d > n/2 {
+		d -= n
+	}
+	return d
+}
+
+// wrap returns l moved back inside the border across the edges that join, see Topology.
+// Coordinates across an edge that doesn't join are left as they are.
+func (s Settings) wrap(l Location) Location {
+	if s.wrapsX() {
+		l.X = wrapAxis(l.X, s.Size.MinX+1, s.Size.MaxX-1)
+	}
+	if s.wrapsY() {
+		l.Y = wrapAxis(l.Y, s.Size.MinY+1, s.Size.MaxY-1)
+	}
+	return l
+}
+
+// offset returns the x, y, z magnitude of the shortest way from a to b, across the edges that join
+func (s Settings) offset(a, b Location) (x, y, z int32) {
+	x, y, z = b.X-a.X, b.Y-a.Y, b.Z-a.Z
+	if s.wrapsX() {
+		x = offsetAxis(a.X, b.X, s.Size.MaxX-s.Size.MinX-1)
+	}
+	if s.wrapsY() {
+		y = offsetAxis(a.Y, b.Y, s.Size.MaxY-s.Size.MinY-1)
+	}
+	return x, y, z
+}
+
+// distance returns the number of single steps (diagonals included) from a to b, across the edges that join
+func (s Settings) distance(a, b Location) int32 {
+	x, y, z := s.offset(a, b)
+	return chebyshev(Location{}, Location{x, y, z})
+}
+
+// distanceSquared returns the squared straight line distance between a and b, across the edges that join
+func (s Settings) distanceSquared(a, b Location) int64 {
+	x, y, z := s.offset(a, b)
+	return distanceSquared(Location{}, Location{x, y, z})
+}
+
+// wrap returns l moved back inside the border across the edges that join, see Settings.Topology
+func (w *World) wrap(l Location) Location {
+	return w.settings.wrap(l)
+}
+
+// step returns where one step of x, y, z magnitude from l leads
+func (w *World) step(l Location, x, y, z int32) Location {
+	return w.wrap(Location{l.X + x, l.Y + y, l.Z + z})
+}
+
+// distance returns the number of single steps (diagonals included) from a to b, see Settings.Topology
+func (w *World) distance(a, b Location) int32 {
+	return w.settings.distance(a, b)
+}
+
+// spans returns the ranges of [v-d, v+d] on an axis from min to max, split in two where it wraps around
+func spans(v, d, min, max int32, wraps bool) [][2]int32 {
+	lo, hi := v-d, v+d
+	if !wraps {
+		return [][2]int32{{lo, hi}}
+	}
+	if hi-lo >= max-min {
+		return [][2]int32{{min, max}}
+	}
+	lo, hi = wrapAxis(lo, min, max), wrapAxis(hi, min, max)
+	if lo <= hi {
+		return [][2]int32{{lo, hi}}
+	}
+	return [][2]int32{{min, hi}, {lo, max}}
+}
+
+// boxesAround returns the boxes, as min and max corners, covering the cells at most d steps from l on its Z level.
+// Across edges that join the square around l is split where it wraps around, the boxes never overlap.
+func (s Settings) boxesAround(l Location, d int32) [][2]Location {
+	var boxes [][2]Location
+	for _, xs := range spans(l.X, d, s.Size.MinX+1, s.Size.MaxX-1, s.wrapsX()) {
+		for _, ys := range spans(l.Y, d, s.Size.MinY+1, s.Size.MaxY-1, s.wrapsY()) {
+			boxes = append(boxes, [2]Location{{xs[0], ys[0], l.Z}, {xs[1], ys[1], l.Z}})
+		}
+	}
+	return boxes
+}
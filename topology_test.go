@@ -0,0 +1,173 @@
+package world
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// genTopologyWorld returns a test world whose edges connect as topology says
+func genTopologyWorld(topology Topology) *World {
+	s := genWorld().settings
+	s.Topology = topology
+	return NewWorld("Wrapped", s, false)
+}
+
+func TestWrap(t *testing.T) {
+	torus, cylinder, bounded := genTopologyWorld(TopologyTorus), genTopologyWorld(TopologyCylinder), genWorld()
+
+	Convey("Locations across edges that join come back on the other side.", t, func() {
+		So(torus.wrap(Location{10, 10, 0}), ShouldResemble, Location{-9, -9, 0})
+		So(torus.wrap(Location{-11, 0, 0}), ShouldResemble, Location{8, 0, 0})
+		So(cylinder.wrap(Location{10, 10, 0}), ShouldResemble, Location{-9, 10, 0})
+		So(bounded.wrap(Location{10, 10, 0}), ShouldResemble, Location{10, 10, 0})
+	})
+
+	Convey("Distances go the short way round.", t, func() {
+		So(torus.distance(Location{9, 9, 0}, Location{-9, -9, 0}), ShouldEqual, 1)
+		So(cylinder.distance(Location{9, 9, 0}, Location{-9, -9, 0}), ShouldEqual, 18)
+		So(bounded.distance(Location{9, 9, 0}, Location{-9, -9, 0}), ShouldEqual, 18)
+
+		x, y, z := torus.settings.offset(Location{9, 0, 0}, Location{-8, 0, 0})
+		So([]int32{x, y, z}, ShouldResemble, []int32{2, 0, 0})
+		x, y, z = torus.settings.offset(Location{-8, 0, 0}, Location{9, 0, 0})
+		So([]int32{x, y, z}, ShouldResemble, []int32{-2, 0, 0})
+	})
+
+	Convey("Corners have neighbors on the other sides.", t, func() {
+		corner := Location{9, 9, 0}
+		So(torus.LocationNeighbors(corner, 1), ShouldHaveLength, 8)
+		So(torus.LocationNeighbors(corner, 1), ShouldContain, Location{-9, -9, 0})
+		So(cylinder.LocationNeighbors(corner, 1), ShouldHaveLength, 5)
+		So(bounded.LocationNeighbors(corner, 1), ShouldHaveLength, 3)
+		So(torus.totalNeighbors(corner, 2), ShouldEqual, 24)
+	})
+
+	Convey("On grids smaller than the view every cell is seen once.", t, func() {
+		s := genWorld().settings
+		s.Topology = TopologyTorus
+		s.Size = &Size{MaxX: 3, MaxY: 3, MinX: -1, MinY: -1}
+		small := NewWorld("Small", s, false)
+		So(small.LocationNeighbors(Location{0, 0, 0}, 2), ShouldHaveLength, 8)
+	})
+
+	Convey("Spawn locations are spread out where corners touch.", t, func() {
+		So(bounded.SpawnLocations(), ShouldContain, Location{9, 9, 0})
+		for _, w := range []*World{torus, cylinder} {
+			locations := w.SpawnLocations()
+			for i, a := range locations {
+				for _, b := range locations[i+1:] {
+					So(w.distance(a, b), ShouldBeGreaterThan, 5)
+				}
+			}
+		}
+	})
+
+	Convey("Topologies must be known.", t, func() {
+		s := genWorld().settings
+		s.Topology = TopologyCylinder
+		So(s.Validate(), ShouldBeNil)
+		s.Topology = "sphere"
+		So(fieldErrors(s.Validate()), ShouldResemble, []string{"Topology"})
+	})
+}
+
+func TestWrappedMovement(t *testing.T) {
+	w := genTopologyWorld(TopologyTorus)
+	w.SetHomebase("red", Location{0, -5, 0})
+	r := &eventRecorder{}
+	w.AddEventSink(r)
+	peep, _ := w.NewPeep("red", Location{9, 0, 0})
+	other, _ := w.NewPeep("red", Location{-8, 0, 0})
+
+	Convey("Peeps see and head for peeps across the edge.", t, func() {
+		So(w.visibleNeighbors(peep), ShouldContainKey, other.Location())
+		w.Publish()
+		detail, _ := w.View().Peep(peep.ID())
+		So(detail.Neighbors, ShouldHaveLength, 1)
+		So(detail.Neighbors[0].ID, ShouldEqual, other.ID())
+
+		path, err := w.FindPath(peep, other.Location())
+		So(err, ShouldBeNil)
+		So(path, ShouldResemble, []Location{{-9, 0, 0}, {-8, 0, 0}})
+
+		x, y, z := w.nextStepTo(peep, other.Location(), w.random)
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, 0, 0})
+		x, y, z = w.NextMoveToGetFromTo(peep.Location(), other.Location())
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, 0, 0})
+		x, y, z = w.NextMoveToGetAwayFrom(other.Location(), peep.Location())
+		So([]int32{x, y, z}, ShouldResemble, []int32{1, 0, 0})
+	})
+
+	Convey("Moving off one side comes back on the other.", t, func() {
+		So(w.Move(peep, 1, 0, 0), ShouldBeNil)
+		So(peep.Location(), ShouldResemble, Location{-9, 0, 0})
+
+		moves := r.ofType(EventMove)
+		So(*moves[len(moves)-1].From, ShouldResemble, Location{9, 0, 0})
+		So(*moves[len(moves)-1].Location, ShouldResemble, Location{-9, 0, 0})
+
+		So(w.Move(peep, 0, 10, 0), ShouldBeNil) // half way round
+		So(peep.Location(), ShouldResemble, Location{-9, -9, 0})
+	})
+
+	Convey("Cylinders stop at the top and bottom.", t, func() {
+		c := genTopologyWorld(TopologyCylinder)
+		p, _ := c.NewPeep("red", Location{9, 9, 0})
+		So(c.Move(p, 0, 1, 0), ShouldNotBeNil)
+		err := c.CheckMovementOutsideGrid(p.Location(), 1, 1, 0)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, Location{-9, 10, 0}.String())
+		So(c.Move(p, 1, 0, 0), ShouldBeNil)
+		So(p.Location(), ShouldResemble, Location{-9, 9, 0})
+	})
+
+	Convey("Renderers know which edges join.", t, func() {
+		So(w.Frame().Topology, ShouldEqual, TopologyTorus)
+		So(resetMessage(w.Frame()).Topology, ShouldEqual, TopologyTorus)
+		So(genWorld().Frame().Topology, ShouldEqual, Topology(""))
+		So(borderVisuals(true).Char, ShouldEqual, '·')
+		So(borderVisuals(false).Char, ShouldEqual, ' ')
+	})
+}
+
+func TestTorusWorld(t *testing.T) {
+	enableMoves(t)
+
+	gen := func(seed int64) *World {
+		s := genSeededWorld(seed).settings
+		s.Topology = TopologyTorus
+		w := NewWorld("Torus", s, false)
+		w.SetDefaultHomebases()
+		return w
+	}
+
+	Convey("Peeps stay on the grid, and crossing edges plays out the same every time.", t, func() {
+		w := gen(7)
+		r := &eventRecorder{}
+		w.AddEventSink(r)
+		So(turnHistory(w, 100), ShouldResemble, turnHistory(gen(7), 100))
+
+		var wrapped int
+		for _, m := range r.ofType(EventMove) {
+			So(w.IsOutsideGrid(m.Location.X, m.Location.Y, m.Location.Z), ShouldBeFalse)
+			if chebyshev(*m.From, *m.Location) > 1 {
+				wrapped++
+			}
+		}
+		So(wrapped, ShouldBeGreaterThan, 0)
+	})
+
+	Convey("The topology is saved and the world continues like the original.", t, func() {
+		original := gen(9)
+		turnHistory(original, 50)
+
+		var saved bytes.Buffer
+		So(original.Snapshot(&saved), ShouldBeNil)
+		restored, err := LoadWorld(bytes.NewReader(saved.Bytes()), false)
+		So(err, ShouldBeNil)
+		So(restored.Settings().Topology, ShouldEqual, TopologyTorus)
+		So(turnHistory(restored, 50), ShouldResemble, turnHistory(original, 50))
+	})
+}
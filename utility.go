@@ -0,0 +1,171 @@
+package world
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+)
+
+// Score is how much an exister wants to do an action, between 0 and 1, with the considerations behind it
+type Score struct {
+	Action         string   `json:"action"`
+	Value          float64  `json:"score"`
+	Considerations []Rating `json:"considerations,omitempty"`
+}
+
+// Rating is the value of one consideration when scoring an action
+type Rating struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// Consideration rates one thing an action cares about, from 0 (rules the action out) to 1 (all for it)
+type Consideration struct {
+	Name string
+	Rate func(w *World, e Exister) float64
+}
+
+// Utility scores an action as weight times the product of its considerations.
+// Every consideration is clamped to [0, 1], and so is the score.
+func Utility(w *World, e Exister, weight float64, considerations ...Consideration) Score {
+	s := Score{Value: clamp(weight)}
+	for _, c := range considerations {
+		r := clamp(c.Rate(w, e))
+		s.Considerations = append(s.Considerations, Rating{c.Name, r})
+		s.Value *= r
+	}
+	return s
+}
+
+// clamp returns v limited to [0, 1], NaN counts as 0
+func clamp(v float64) float64 {
+	if math.IsNaN(v) || v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// Built-in considerations, see lookAction, moveAction and skipAction
+
+// staleMemory is 1 once a peep has forgotten what it saw, and grows towards 0.2 until then
+var staleMemory = Consideration{"stale_memory", func(w *World, e Exister) float64 {
+	p, ok := e.(Perceiver)
+	if !ok {
+		return 0
+	}
+	since := float64(w.turn - p.LookTurn())
+	remember := float64(w.settings.PeepRememberTurns)
+	if since >= remember {
+		return 1
+	}
+	return 0.2 * since / remember
+}}
+
+// neighborsSeen is higher the more alive neighbors a peep saw when it last looked around
+var neighborsSeen = Consideration{"neighbors_seen", func(w *World, e Exister) float64 {
+	var seen int
+	if p, ok := e.(Perceiver); ok {
+		for _, n := range p.NeighborsFromLook() {
+			if isAlive(n) {
+				seen++
+			}
+		}
+	}
+	return 0.5 + float64(seen)/16
+}}
+
+// readyToSpawn is 1 for peeps of spawn age that have not spawned recently, lower otherwise
+var readyToSpawn = Consideration{"ready_to_spawn", func(w *World, e Exister) float64 {
+	if r, ok := e.(Reproducer); ok && w.OfSpawnAge(e) && w.turn-r.SpawnTurn() >= w.settings.PeepSpawnInterval {
+		return 1
+	}
+	return 0.75
+}}
+
+// awayFromHome is higher the further a peep is from its homebase, relative to how far it can see
+var awayFromHome = Consideration{"away_from_home", func(w *World, e Exister) float64 {
+	s, ok := e.(Social)
+	if !ok {
+		return 0.75
+	}
+	d := w.distance(e.Location(), s.Homebase())
+	view := viewDistance(e)
+	if view < 1 {
+		view = 1
+	}
+	return 0.75 + 0.25*float64(d)/float64(4*view)
+}}
+
+// oldAge is the part of its life a peep has lived
+var oldAge = Consideration{"old_age", func(w *World, e Exister) float64 {
+	lifespan := genome(e).Lifespan
+	if lifespan <= 0 {
+		return 0
+	}
+	return float64(age(e)) / float64(lifespan)
+}}
+
+// chebyshev returns the number of single steps (diagonals included) from a to b
+func chebyshev(a, b Location) int32 {
+	d := abs(a.X - b.X)
+	if y := abs(a.Y - b.Y); y > d {
+		d = y
+	}
+	if z := abs(a.Z - b.Z); z > d {
+		d = z
+	}
+	return d
+}
+
+// abs returns the absolute value of v
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// scoreActions returns the score of every registered action for e, best first.
+// Equal scores keep the order the actions were registered in.
+func (w *World) scoreActions(e Exister) []Score {
+	scores := make([]Score, len(w.actions))
+	for i, a := range w.actions {
+		scores[i] = a.Score(w, e)
+		scores[i].Action = a.Name()
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Value > scores[j].Value
+	})
+	return scores
+}
+
+// Decisions returns the scored actions of the peep with id on the last turn, best first
+func (w *World) Decisions(id string) ([]Score, error) {
+	scores, ok := w.decisions[id]
+	if !ok {
+		return nil, fmt.Errorf("no decisions for peep: %v", id)
+	}
+	return scores, nil
+}
+
+// DecisionsHandler serves the scored actions of a peep on the last turn
+func (w *World) DecisionsHandler(writer http.ResponseWriter, r *http.Request) {
+	v := w.View()
+	id := mux.Vars(r)["id"]
+	scores, ok := v.decisions[id]
+	if !ok {
+		writeError(writer, http.StatusNotFound, fmt.Errorf("no decisions for peep: %v", id))
+		return
+	}
+	writeJSON(writer, struct {
+		Turn    Turn    `json:"turn"`
+		ID      string  `json:"id"`
+		Actions []Score `json:"actions"`
+	}{v.Info.Turn, id, scores})
+}
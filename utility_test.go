@@ -0,0 +1,104 @@
+package world
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUtility(t *testing.T) {
+	w := genWorld()
+	p, _ := w.NewPeep("red", Location{1, 1, 0})
+
+	rate := func(name string, v float64) Consideration {
+		return Consideration{name, func(*World, Exister) float64 { return v }}
+	}
+
+	Convey("Scores are the weight times all considerations.", t, func() {
+		s := Utility(w, p, 0.5, rate("a", 0.5), rate("b", 0.4))
+		So(s.Value, ShouldAlmostEqual, 0.1)
+		So(s.Considerations, ShouldResemble, []Rating{{"a", 0.5}, {"b", 0.4}})
+	})
+
+	Convey("Considerations and scores stay between 0 and 1.", t, func() {
+		s := Utility(w, p, 2, rate("high", 3), rate("nan", math.NaN()))
+		So(s.Value, ShouldEqual, 0)
+		So(s.Considerations, ShouldResemble, []Rating{{"high", 1}, {"nan", 0}})
+
+		So(Utility(w, p, 2, rate("high", 3)).Value, ShouldEqual, 1)
+		So(Utility(w, p, 1, rate("low", -1)).Value, ShouldEqual, 0)
+	})
+
+	Convey("Peeps that forgot what they saw look around before anything else.", t, func() {
+		enableMoves(t)
+		w.settings.PeepRememberTurns = 2
+		p.SetLookTurn(0)
+		w.turn = 2
+		So(w.scoreActions(p)[0].Action, ShouldEqual, "look")
+
+		w.turn = 1
+		So(w.scoreActions(p)[0].Action, ShouldNotEqual, "look")
+	})
+}
+
+func TestDecisions(t *testing.T) {
+	w := genSeededWorld(3)
+	enableMoves(t)
+	w.settings.NewPeepMax = 0
+	w.settings.PeepRememberTurns = 1
+
+	red, _ := w.NewPeep("red", Location{1, 1, 0})
+	w.NewPeep("blue", Location{2, 2, 0})
+	w.NextTurn()
+
+	Convey("The scored actions of the last turn are kept for every peep, best first.", t, func() {
+		scores, err := w.Decisions(red.ID())
+		So(err, ShouldBeNil)
+		So(len(scores), ShouldEqual, 3)
+		for i := 1; i < len(scores); i++ {
+			So(scores[i-1].Value, ShouldBeGreaterThanOrEqualTo, scores[i].Value)
+		}
+		So(scores[0].Action, ShouldEqual, "look") // it forgot what it saw
+		So(scores[0].Considerations, ShouldResemble, []Rating{{"stale_memory", 1}})
+
+		_, err = w.Decisions("nobody")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Decisions are served for debugging.", t, func() {
+		var got struct {
+			Turn    Turn
+			ID      string
+			Actions []Score
+		}
+		So(get(w, "/api/peeps/"+red.ID()+"/decisions", &got), ShouldEqual, http.StatusOK)
+		So(got.Turn, ShouldEqual, 1)
+		So(got.ID, ShouldEqual, red.ID())
+		scores, _ := w.Decisions(red.ID())
+		So(got.Actions, ShouldResemble, scores)
+
+		So(get(w, "/api/peeps/nobody/decisions", nil), ShouldEqual, http.StatusNotFound)
+	})
+
+	Convey("Equal scores go to the action registered first.", t, func() {
+		w.settings.PeepRememberTurns = 5
+		w.RegisterAction(constantAction{"first", 0.9})
+		w.RegisterAction(constantAction{"second", 0.9})
+		w.NextTurn()
+		scores, _ := w.Decisions(red.ID())
+		So(scores[0].Action, ShouldEqual, "first")
+		So(scores[1].Action, ShouldEqual, "second")
+	})
+}
+
+// constantAction always has the same score and does nothing
+type constantAction struct {
+	name  string
+	score float64
+}
+
+func (a constantAction) Name() string                      { return a.name }
+func (a constantAction) Score(w *World, e Exister) Score   { return Score{Value: a.score} }
+func (a constantAction) Execute(w *World, e Exister) error { return nil }
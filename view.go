@@ -0,0 +1,164 @@
+package world
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// View is an immutable picture of the world at the end of a turn.
+//
+// The simulation goroutine is the only one changing the world. After every turn it publishes
+// a new View (see Publish), which other goroutines (http handlers, user input) read instead of the world.
+// A View is never changed once published.
+type View struct {
+	Frame          *Frame
+	Info           WorldInfo
+	Settings       Settings
+	SpawnLocations []Location
+	Peeps          []PeepDetail // every peep on the grid, ordered by location; Neighbors are not set, see Peep
+	Food           []FoodPatch  // ordered by location
+
+	byID       map[string]int   // index into Peeps
+	byLocation map[Location]int // index into Peeps
+	family     familyTree
+	decisions  map[string][]Score // never changed once a turn is over, see doActions
+}
+
+// newView returns a view of the world as it is right now
+func (w *World) newView() *View {
+	settings := w.Settings()
+	size := *settings.Size
+	settings.Size = &size
+
+	v := &View{
+		Frame:          w.Frame(),
+		Info:           w.WorldInfo(),
+		Settings:       settings,
+		SpawnLocations: w.SpawnLocations(),
+		Food:           w.Food(),
+		byID:           make(map[string]int),
+		byLocation:     make(map[Location]int),
+		family:         w.family.viewAt(w.turn),
+		decisions:      w.decisions,
+	}
+
+	for _, e := range w.allExisters() {
+		p, ok := e.(*Peep)
+		if !ok {
+			continue // predators are counted in Info
+		}
+		detail := PeepDetail{
+			PeepInfo: w.peepInfo(p),
+			Parents:  []string{},
+			Children: []string{},
+			Met:      []MetInfo{},
+		}
+		if l, err := w.Lineage(p.ID()); err == nil {
+			detail.Generation = l.Generation
+			detail.Parents = append(detail.Parents, l.Parents...)
+			detail.Children = append(detail.Children, l.Children...)
+		}
+		for other, turn := range p.Met() {
+			detail.Met = append(detail.Met, MetInfo{ID: other.ID(), Turn: turn})
+		}
+		sort.Slice(detail.Met, func(i, j int) bool {
+			return detail.Met[i].ID < detail.Met[j].ID
+		})
+
+		v.byID[detail.ID] = len(v.Peeps)
+		v.byLocation[detail.Location] = len(v.Peeps)
+		v.Peeps = append(v.Peeps, detail)
+	}
+	return v
+}
+
+// Publish makes the current state of the world visible to other goroutines, see View.
+// NextTurn and Run publish on their own; call Publish after changing the world by hand.
+// Only the goroutine changing the world may call Publish.
+func (w *World) Publish() *View {
+	v := w.newView()
+	w.published.Store(v)
+	return v
+}
+
+// View returns the last published view of the world, it is safe to call from any goroutine.
+func (w *World) View() *View {
+	return w.published.Load().(*View)
+}
+
+// Peep returns everything about the peep with id, including the alive neighbors within its view distance
+func (v *View) Peep(id string) (PeepDetail, bool) {
+	i, ok := v.byID[id]
+	if !ok {
+		return PeepDetail{}, false
+	}
+
+	detail := v.Peeps[i]
+	detail.Neighbors = []PeepInfo{}
+
+	d := detail.Genome.ViewDistance
+	l := detail.Location
+	seen := make(map[int]bool) // the same peep comes round again on small grids that wrap
+	for y := l.Y - d; y <= l.Y+d; y++ {
+		for x := l.X - d; x <= l.X+d; x++ {
+			n, ok := v.byLocation[v.Settings.wrap(Location{x, y, l.Z})]
+			if !ok || n == i || !v.Peeps[n].Alive || seen[n] {
+				continue
+			}
+			seen[n] = true
+			detail.Neighbors = append(detail.Neighbors, v.Peeps[n].PeepInfo)
+		}
+	}
+	return detail, true
+}
+
+// Show prints world information.
+func (v *View) Show(writer io.Writer) {
+	fmt.Fprintf(writer, "%v\n", strings.Repeat("-", 80))
+	fmt.Fprintf(writer, "Name: %v\n", v.Info.Name)
+	fmt.Fprintf(writer, "Turn: %v\n", v.Info.Turn)
+	fmt.Fprintf(writer, "Peeps Alive/MaxAlive: %v/%v\n", v.Info.Alive, v.Settings.MaxPeeps)
+	fmt.Fprintf(writer, "Peep Max/Avg/Min Age: %v/%v/%v\n", v.Info.Ages.Max, v.Info.Ages.Avg, v.Info.Ages.Min)
+	fmt.Fprintf(writer, "Genders: %v\n", v.Info.Genders)
+	if v.Settings.Predators > 0 {
+		fmt.Fprintf(writer, "Predators Alive: %v\n", v.Info.Predators)
+	}
+
+}
+
+// ShowSettings prints world settings.
+func (v *View) ShowSettings(writer io.Writer) {
+	fmt.Fprintf(writer, "%v\n", strings.Repeat("-", len("Settings")))
+	fmt.Fprintf(writer, "Settings\n")
+	fmt.Fprintf(writer, "%v\n", strings.Repeat("-", len("Settings")))
+
+	s := reflect.ValueOf(&v.Settings).Elem()
+	typeOfT := s.Type()
+	for i := 0; i < s.NumField(); i++ {
+		f := s.Field(i)
+		fmt.Fprintf(writer, "%s = %v\n", typeOfT.Field(i).Name, f.Interface())
+	}
+}
+
+// ShowSpawnPoints prints the spawn point information
+func (v *View) ShowSpawnPoints(writer io.Writer) {
+	fmt.Fprintf(writer, "%v\n", strings.Repeat("*", 40))
+	fmt.Fprintf(writer, "World Spawn Points:\n\n")
+	fmt.Fprintf(writer, "%v\n", v.SpawnLocations)
+	fmt.Fprintf(writer, "%v\n", strings.Repeat("*", 40))
+}
+
+// ShowGrid prints the grid and its alive occupants
+func (v *View) ShowGrid(writer io.Writer) {
+	fmt.Fprintf(writer, "%v\n", strings.Repeat("*", 40))
+	fmt.Fprintf(writer, "World GRID:\n\n")
+	for _, p := range v.Peeps {
+		if p.Alive {
+			fmt.Fprintf(writer, "%v age:%v gender:%v location:%v\n", p.ID, p.Age, p.Gender, p.Location)
+		}
+	}
+	fmt.Fprintf(writer, "%v\n", strings.Repeat("*", 40))
+}
@@ -0,0 +1,136 @@
+package world
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	termbox "github.com/nsf/termbox-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestView(t *testing.T) {
+	w := genWorld()
+	p, _ := w.NewPeep("red", Location{1, 1, 0})
+
+	Convey("Views only change when published.", t, func() {
+		before := w.View()
+		So(before.Peeps, ShouldBeEmpty)
+
+		after := w.Publish()
+		So(w.View(), ShouldEqual, after)
+		So(after.Peeps, ShouldHaveLength, 1)
+		So(before.Peeps, ShouldBeEmpty)
+	})
+
+	Convey("Views don't change with the world.", t, func() {
+		v := w.View()
+		p.age = 30
+		w.SetTurnTime(time.Hour)
+		w.NewPeep("blue", Location{2, 1, 0})
+
+		detail, ok := v.Peep(p.ID())
+		So(ok, ShouldBeTrue)
+		So(detail.Age, ShouldEqual, 0)
+		So(detail.Neighbors, ShouldBeEmpty)
+		So(v.Settings.TurnTime, ShouldEqual, 0)
+
+		detail, _ = w.Publish().Peep(p.ID())
+		So(detail.Age, ShouldEqual, 30)
+		So(detail.Neighbors, ShouldHaveLength, 1)
+		So(w.View().Settings.TurnTime, ShouldEqual, time.Hour)
+	})
+
+	Convey("Views print like the world.", t, func() {
+		var fromWorld, fromView bytes.Buffer
+		w.Show(&fromWorld)
+		w.ShowGrid(&fromWorld)
+		w.View().Show(&fromView)
+		w.View().ShowGrid(&fromView)
+		So(fromView.String(), ShouldEqual, fromWorld.String())
+		So(fromView.String(), ShouldContainSubstring, p.ID()+" age:30 gender:red location:(1, 1, 0)")
+	})
+}
+
+// TestConcurrentReaders reads the world from other goroutines while it runs, run it with -race.
+func TestConcurrentReaders(t *testing.T) {
+	enableMoves(t)
+
+	w := genSeededWorld(7)
+	w.settings.TurnTime = 0
+	w.control.turnTime = 0
+	server := httptest.NewServer(w.Router())
+	defer server.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/live/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	done := make(chan error)
+	go func() {
+		done <- w.Loop(200)
+	}()
+
+	var readers sync.WaitGroup
+	paths := []string{
+		"/", "/metrics", "/api/world", "/api/settings", "/api/peeps?alive=true",
+		"/api/family", "/api/family?format=dot", "/api/control",
+	}
+	for _, path := range paths {
+		readers.Add(1)
+		go func(path string) {
+			defer readers.Done()
+			for i := 0; i < 20; i++ {
+				resp, err := http.Get(server.URL + path)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+
+				for _, p := range w.View().Peeps {
+					http.Get(server.URL + "/api/peeps/" + p.ID)
+					break
+				}
+			}
+		}(path)
+	}
+
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		for i := 0; i < 20; i++ {
+			http.Post(server.URL+"/api/control/speed?turn_time=0s", "", nil)
+			http.Post(server.URL+"/api/control/pause", "", nil)
+			http.Post(server.URL+"/api/control/resume", "", nil)
+		}
+	}()
+	go func() {
+		defer readers.Done()
+		controller := NewTermboxController(w)
+		for i := 0; i < 20; i++ {
+			controller.Handle(termbox.Event{Type: termbox.EventKey, Key: termbox.KeySpace})
+			if _, _, err := ws.ReadMessage(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	readers.Wait()
+	w.Resume()
+
+	Convey("The world runs while being read.", t, func() {
+		So(<-done, ShouldBeNil)
+		So(w.View().Info.Turn, ShouldEqual, 200)
+	})
+}
@@ -1,22 +1,17 @@
 package world
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
 	"os"
-	"reflect"
-	"strings"
+	"sync/atomic"
 	"time"
-
-	"github.com/gorilla/mux"
-	termbox "github.com/nsf/termbox-go"
 )
 
 var (
-	// random = rand.New(rand.NewSource(time.Now().UnixNano()))
 	allowMoves = true // for testing, turns off random moves.
 )
 
@@ -33,14 +28,29 @@ type neighborViewDistanceCache struct {
 type World struct {
 	name              string
 	settings          Settings
-	turn              Turn               // the current turn
-	eventQueue        chan termbox.Event // for catching user input
-	grid              *Grid              // Map of coordinates to occupant
+	turn              Turn     // the current turn
+	renderer          Renderer // draws the world after each turn
+	grid              *Grid    // Map of coordinates to occupant
 	stats             *stats
 	locationNeighbors map[neighborViewDistanceCache][]Location // cache of location/view distance -> list of neighbor locations
-	okToAdvance       bool                                     // for debugging
 	debug             bool
 	homebase          map[PeepGender]Location
+	random            *rand.Rand         // source of all randomness in this world, seeded from settings.Seed
+	randomSource      *randomSource      // the state of random
+	eventSinks        []EventSink        // receive all events
+	actions           []Action           // what existers can do on their turn, see RegisterAction
+	decisions         map[string][]Score // scored actions of every exister on the last turn, by id
+	paths             pathCache          // paths existers are following, see nextStepTo
+	food              map[Location]*FoodPatch
+	terrain           map[Location]Terrain // cells that aren't plain ground, see LoadTerrain
+	terrainCells      []TerrainCell        // the same, ordered by location
+	turnEvents        []Event              // events of the current turn
+	turnRecorders     []TurnRecorder       // receive the stats of every turn
+	family            familyTree           // lineage of every peep ever born
+	server            *http.Server         // serves world information, see Run
+	control           *runControl          // run state, see Loop
+	live              *LiveRenderer        // streams the world to browsers, see Router
+	published         atomic.Value         // the last published *View, see View
 }
 
 type Turn int64
@@ -65,21 +75,47 @@ func ListContainsString(list []string, s string) bool {
 	return false
 }
 
-func NewWorld(name string, settings Settings, eventQueue chan termbox.Event, debug bool) *World {
-	rand.Seed(time.Now().UnixNano())
-	return &World{
-		name:       name,
-		settings:   settings,
-		eventQueue: eventQueue, // keyboard input events
-		grid: &Grid{
-			size:    settings.Size,
-			objects: NewDmap(), // empty grid
-		},
-		stats:             newStats(),
+// NewWorld returns a new, empty world.
+// All random decisions are drawn from a source seeded with settings.Seed; if that is 0,
+// a seed is picked from the clock and recorded in the world settings so the run can be reproduced.
+// The world is not drawn anywhere until a Renderer is set with SetRenderer.
+func NewWorld(name string, settings Settings, debug bool) *World {
+	if settings.Seed == 0 {
+		settings.Seed = time.Now().UnixNano()
+	}
+	source := newRandomSource(settings.Seed)
+	w := &World{
+		name:              name,
+		settings:          settings,
+		renderer:          NoopRenderer{},
+		grid:              NewGrid(),
+		stats:             newStats(maxLifespan(settings.MaxAge)),
 		locationNeighbors: make(map[neighborViewDistanceCache][]Location),
 		debug:             debug,
 		homebase:          make(map[PeepGender]Location),
+		random:            rand.New(source),
+		randomSource:      source,
+		control:           newRunControl(settings.TurnTime),
+		live:              NewLiveRenderer(),
+		family:            newFamilyTree(),
+		actions:           defaultActions(settings),
+		food:              make(map[Location]*FoodPatch),
+		terrain:           make(map[Location]Terrain),
+	}
+	w.AddEventSink(w.stats)
+	if settings.foodEnabled() {
+		w.placeFood()
+	}
+	w.Publish()
+	return w
+}
+
+// SetRenderer sets the renderer used to draw the world after each turn
+func (w *World) SetRenderer(r Renderer) {
+	if r == nil {
+		r = NoopRenderer{}
 	}
+	w.renderer = r
 }
 
 // handleOvercrowding handles the cases when a peep is completely surrounded
@@ -88,10 +124,12 @@ func (w *World) handleOvercrowding(p *Peep) {
 	neighborLocations := w.LocationNeighbors(p.Location(), 1)
 
 	var genderCount = make(map[PeepGender]int)
-	for _, l := range neighborLocations {
-		if e := w.LocationExister(l); e != nil {
-			genderCount[e.Gender()]++
+	for _, e := range w.existersAround(p.Location(), 1) {
+		var gender PeepGender // anything that is not a peep is of no gender, and so other
+		if s, ok := e.(Social); ok {
+			gender = s.Gender()
 		}
+		genderCount[gender]++
 	}
 
 	// Check if surrounded and kill if settings say so
@@ -104,7 +142,7 @@ func (w *World) handleOvercrowding(p *Peep) {
 			}
 		}
 		if len(neighborLocations) == otherNeighbors {
-			p.Die(w.turn)
+			w.kill(p, DeathSurroundedByOther)
 		}
 
 	}
@@ -112,7 +150,7 @@ func (w *World) handleOvercrowding(p *Peep) {
 	if w.settings.KillIfSurroundedBySame {
 		// If all locations around are take up by same gender peeps
 		if len(neighborLocations) == genderCount[p.Gender()] {
-			p.Die(w.turn)
+			w.kill(p, DeathSurroundedBySame)
 		}
 	}
 
@@ -123,112 +161,113 @@ func (w *World) handleOvercrowding(p *Peep) {
 		}
 
 		if len(neighborLocations) == allNeighbors {
-			p.Die(w.turn)
+			w.kill(p, DeathSurrounded)
 		}
 	}
 }
 
+// kill kills a peep, unless it is already dead
+func (w *World) kill(p *Peep, cause DeathCause) {
+	if !p.IsAlive() {
+		return
+	}
+	p.dieOf(w.turn, cause)
+	w.emitDeath(p)
+}
+
 // NextTurn advances the world to the next turn.
+// User input is not handled here, see TermboxController.
 func (w *World) NextTurn() error {
-	// Check if we should exit
-	select {
-	case ev := <-w.eventQueue:
-		if ev.Type == termbox.EventKey && ev.Key == termbox.KeyEsc {
-			return errors.New("Exiting...")
-		}
-		if ev.Type == termbox.EventKey && ev.Key == termbox.KeySpace {
-			w.Show(os.Stderr)
-		}
-		if ev.Type == termbox.EventKey && ev.Key == termbox.KeyCtrlS {
-			w.ShowSettings(os.Stderr)
-		}
-		if ev.Type == termbox.EventKey && ev.Key == termbox.KeyEnter {
-			w.okToAdvance = true
-		}
-	default:
-		if w.debug {
-			if !w.okToAdvance {
-				return nil
-			} else {
-				Log("Advancing to next turn...")
-			}
+	defer w.stats.turnTime.UpdateSince(time.Now())
 
-		}
+	w.turnEvents = nil
+	w.turn++
+	w.randomSource.reseed(w.turn)
 
-		// Update stats
-		w.stats.peepsAlive.Update(w.AlivePeepCount())
+	// Peep actions
+	w.doActions()
 
-		// Redraw screen
-		w.Draw()
-
-		w.turn++
-
-		// Peep actions
-		w.doActions()
+	// New peep might be born
+	if err := w.randomPeep(); err != nil {
+		w.emitSpawnBlocked(nil, nil, err)
+	}
 
-		// New peep might be born
-		if err := w.randomPeep(); err != nil {
-			//Log(err)
+	// Age and/or kill existing peeps and predators
+	for _, e := range w.allExisters() {
+		if !isAlive(e) {
+			continue
 		}
+		switch e := e.(type) {
+		case *Peep:
+			w.agePeep(e)
+		case *Predator:
+			w.agePredator(e)
+		}
+	}
+	w.regrowFood()
 
-		// Age and/or kill existing peeps
-		for _, e := range w.allExisters() {
-			peep := e.(*Peep)
+	w.emit(Event{Type: EventTurn})
+	view := w.Publish()
 
-			if !peep.IsAlive() {
-				continue
-			}
-			age, err := peep.AgeOrDie(w.settings.MaxAge, w.settings.RandomDeath, w.turn)
-			if err != nil {
-				w.stats.ages.Update(int64(age))
-			}
-			w.handleOvercrowding(peep)
-		}
+	// Update stats
+	w.stats.update(view)
+	w.recordTurn()
 
-		if w.debug {
-			w.okToAdvance = false
-			w.Show(os.Stderr)
-			w.ShowGrid(os.Stderr)
-			w.ShowSpawnPoints(os.Stderr)
-		}
+	// Redraw screen
+	w.renderer.Draw(view.Frame)
+	w.live.Draw(view.Frame)
 
+	if w.debug {
+		view.Show(os.Stderr)
+		view.ShowGrid(os.Stderr)
+		view.ShowSpawnPoints(os.Stderr)
 	}
 	return nil
 }
 
+// agePeep ages peep, makes it pay for living and kills it if its time has come
+func (w *World) agePeep(peep *Peep) {
+	if _, err := peep.AgeOrDie(peep.Genome().Lifespan, w.settings.RandomDeath, w.turn); err != nil {
+		w.emitDeath(peep)
+		return
+	}
+	w.spendEnergy(peep, w.settings.TurnEnergy)
+	if w.starve(peep) {
+		w.emitDeath(peep)
+		return
+	}
+	w.handleOvercrowding(peep)
+}
+
 // randomPeep creates a new peep at random
 // randomness controlled by world.settings.NewPeepModifier
 // As the world grows, probability of this event goes towards 0
 // Subject to world.settings.MaxPeeps
 func (w *World) randomPeep() error {
 	if w.AlivePeepCount() >= w.settings.NewPeepMax {
-		return fmt.Errorf("Too many peeps (%v) for random spawn.", w.AlivePeepCount())
+		return nil // no spawn is tried, so none is blocked
 	}
 	probability := w.settings.NewPeep - (float64(w.AlivePeepCount()) / w.settings.NewPeepModifier)
-	if rand.Float64() < probability {
-		w.NewPeep("", Location{})
+	if w.random.Float64() < probability {
+		if _, err := w.NewPeepAtHomebase(""); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
 // AlivePeepCount returns the number of alive peeps
 func (w *World) AlivePeepCount() int64 {
-	var peeps int64
-	for _, e := range w.allExisters() {
-		p := e.(*Peep)
-		if p.IsAlive() {
-			peeps++
-		}
-	}
-	return peeps
+	return w.grid.count(func(e Exister) bool {
+		return isPeep(e) && isAlive(e)
+	})
 }
 
 // PeepGenders returns a count of all peep genders
 func (w *World) PeepGenders() map[PeepGender]int64 {
 	genders := make(map[PeepGender]int64)
-	for _, e := range w.allExisters() {
-		p := e.(*Peep)
-		if p.IsAlive() {
+	for _, e := range w.grid.unordered() {
+		if p, ok := e.(*Peep); ok && p.IsAlive() {
 			genders[p.Gender()]++
 		}
 	}
@@ -238,10 +277,8 @@ func (w *World) PeepGenders() map[PeepGender]int64 {
 // PeepMaxAge returns the max age of all peeps
 func (w *World) PeepMaxAge() PeepAge {
 	var max PeepAge
-	for _, e := range w.allExisters() {
-		p := e.(*Peep)
-
-		if p.Age() > max && p.IsAlive() {
+	for _, e := range w.grid.unordered() {
+		if p, ok := e.(*Peep); ok && p.Age() > max && p.IsAlive() {
 			max = p.Age()
 		}
 	}
@@ -253,11 +290,10 @@ func (w *World) PeepMinAge() PeepAge {
 	if w.AlivePeepCount() == 0 {
 		return 0
 	}
-	min := w.settings.MaxAge
+	min := PeepAge(-1) // lifespans may outgrow MaxAge, see Genome
 
-	for _, e := range w.allExisters() {
-		p := e.(*Peep)
-		if p.Age() < min && p.IsAlive() {
+	for _, e := range w.grid.unordered() {
+		if p, ok := e.(*Peep); ok && p.IsAlive() && (min < 0 || p.Age() < min) {
 			min = p.Age()
 		}
 	}
@@ -268,9 +304,8 @@ func (w *World) PeepMinAge() PeepAge {
 func (w *World) PeepAvgAge() PeepAge {
 	var sum PeepAge
 	var alive PeepAge
-	for _, e := range w.allExisters() {
-		p := e.(*Peep)
-		if p.IsAlive() {
+	for _, e := range w.grid.unordered() {
+		if p, ok := e.(*Peep); ok && p.IsAlive() {
 			sum += p.Age()
 			alive++
 		}
@@ -281,77 +316,89 @@ func (w *World) PeepAvgAge() PeepAge {
 	return 0
 }
 
-func (w *World) runWebServer() {
-	r := mux.NewRouter()
-	r.HandleFunc("/", w.HomeHandler)
-	http.Handle("/", r)
-	http.ListenAndServe(":6001", nil)
+// allExisters returns all existers recorded in the world, ordered by location
+func (w *World) allExisters() []Exister {
+	return w.grid.Occupants()
 }
 
-// allExisters returns all existers recorded in the world
-func (w *World) allExisters() []Exister {
-	var all []Exister
-	for _, loc := range w.grid.objects.AllNonEmptyLocations() {
-		if e := w.LocationExister(loc); e != nil {
-			all = append(all, e)
+// existersAround returns the existers within distance of l on the grid, not counting the one at l.
+// Across edges that join, see Topology, existers on the other side of the grid are around too.
+func (w *World) existersAround(l Location, distance int32) []Exister {
+	var around []Exister
+	for _, box := range w.settings.boxesAround(l, distance) {
+		for _, e := range w.grid.Box(box[0], box[1]) {
+			if !e.Location().SameAs(l) {
+				around = append(around, e)
+			}
 		}
 	}
-	return all
+	return around
 }
 
 // Run runs the world.
-func (w *World) Run() {
+// If webAddr is not empty, world information is served over http on that address until Shutdown.
+func (w *World) Run(webAddr string) {
 	Log("Starting world...")
-	go w.runWebServer()
+	w.Publish()
+	if webAddr != "" {
+		w.server = &http.Server{Addr: webAddr, Handler: w.Router()}
+		go w.runWebServer(w.server)
+	}
+}
+
+// Shutdown gracefully stops the web server started by Run, waiting for open requests until ctx is done.
+func (w *World) Shutdown(ctx context.Context) error {
+	if w.server == nil {
+		return nil
+	}
+	return w.server.Shutdown(ctx)
+}
+
+// Turn returns the current turn
+func (w *World) Turn() Turn {
+	return w.turn
 }
 
-// Pause pauses the world.
-func (w *World) Pause() {
+// Settings returns a copy of the world settings
+func (w *World) Settings() Settings {
+	s := w.settings
+	s.TurnTime = w.ControlInfo().TurnTime
+	return s
+}
+
+// SetDefaultHomebases gives each gender one of the SpawnLocations as its homebase
+func (w *World) SetDefaultHomebases() {
+	for gender, loc := range defaultHomebases(w.settings) {
+		w.SetHomebase(gender, loc)
+	}
+}
 
+// defaultHomebases returns the homebases SetDefaultHomebases sets: one spawn location per gender, see SpawnLocations
+func defaultHomebases(s Settings) map[PeepGender]Location {
+	spawnLocations := spawnLocations(s)
+	homebases := make(map[PeepGender]Location)
+	for i, gender := range s.genders() {
+		homebases[gender] = spawnLocations[i%len(spawnLocations)]
+	}
+	return homebases
 }
 
 // ShowGrid prints the grid and its occupants
 func (w *World) ShowGrid(writer io.Writer) {
-	fmt.Fprintf(writer, "%v\n", strings.Repeat("*", 40))
-	fmt.Fprintf(writer, "World GRID:\n\n")
-	for _, e := range w.allExisters() {
-		p := e.(*Peep)
-		if p.IsAlive() {
-			fmt.Fprintf(writer, "%v\n", p.String())
-		}
-	}
-	fmt.Fprintf(writer, "%v\n", strings.Repeat("*", 40))
+	w.newView().ShowGrid(writer)
 }
 
 // ShowSpawnPoints prints the spawn point information
 func (w *World) ShowSpawnPoints(writer io.Writer) {
-	fmt.Fprintf(writer, "%v\n", strings.Repeat("*", 40))
-	fmt.Fprintf(writer, "World Spawn Points:\n\n")
-	fmt.Fprintf(writer, "%v\n", w.SpawnLocations())
-	fmt.Fprintf(writer, "%v\n", strings.Repeat("*", 40))
+	w.newView().ShowSpawnPoints(writer)
 }
 
 // Show prints world information.
 func (w *World) Show(writer io.Writer) {
-	fmt.Fprintf(writer, "%v\n", strings.Repeat("-", 80))
-	fmt.Fprintf(writer, "Name: %v\n", w.name)
-	fmt.Fprintf(writer, "Turn: %v\n", w.turn)
-	fmt.Fprintf(writer, "Peeps Alive/MaxAlive: %v/%v\n", w.AlivePeepCount(), w.settings.MaxPeeps)
-	fmt.Fprintf(writer, "Peep Max/Avg/Min Age: %v/%v/%v\n", w.PeepMaxAge(), w.PeepAvgAge(), w.PeepMinAge())
-	fmt.Fprintf(writer, "Genders: %v\n", w.PeepGenders())
-
+	w.newView().Show(writer)
 }
 
 // ShowSettings prints world settings.
 func (w *World) ShowSettings(writer io.Writer) {
-	fmt.Fprintf(writer, "%v\n", strings.Repeat("-", len("Settings")))
-	fmt.Fprintf(writer, "Settings\n")
-	fmt.Fprintf(writer, "%v\n", strings.Repeat("-", len("Settings")))
-
-	s := reflect.ValueOf(&w.settings).Elem()
-	typeOfT := s.Type()
-	for i := 0; i < s.NumField(); i++ {
-		f := s.Field(i)
-		fmt.Fprintf(writer, "%s = %v\n", typeOfT.Field(i).Name, f.Interface())
-	}
+	w.newView().ShowSettings(writer)
 }
@@ -2,9 +2,9 @@ package world
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
-	termbox "github.com/nsf/termbox-go"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -24,13 +24,16 @@ func genWorld() *World {
 		MaxGenders:       4,
 	}
 
-	// Listen for input events on keyboard, required to test
-	event_queue := make(chan termbox.Event)
-
 	// turn off random moves
 	allowMoves = false
 
-	return NewWorld("Alpha1", *s, event_queue, false)
+	return NewWorld("Alpha1", *s, false)
+}
+
+// enableMoves turns on random moves until the test is over
+func enableMoves(t *testing.T) {
+	allowMoves = true
+	t.Cleanup(func() { allowMoves = false })
 }
 
 func TestListContains(t *testing.T) {
@@ -50,7 +53,7 @@ func TestHomebase(t *testing.T) {
 		loc := Location{3, 4, 0}
 		w.SetHomebase("red", loc)
 
-		peep1, _ := w.NewPeep("red", Location{})
+		peep1, _ := w.NewPeepAtHomebase("red")
 		So(peep1.Homebase().SameAs(loc), ShouldBeTrue)
 	})
 
@@ -85,7 +88,7 @@ func TestOnePeep(t *testing.T) {
 	w.settings.MaxAge = maxAge
 	w.settings.RandomDeath = 0
 
-	peep1, _ := w.NewPeep("red", Location{})
+	peep1, _ := w.NewPeepAtHomebase("red")
 	id := peep1.ID()
 
 	for turn := 0; turn < int(maxAge); turn++ {
@@ -104,3 +107,59 @@ func TestOnePeep(t *testing.T) {
 		w.NextTurn()
 	}
 }
+
+// genSeededWorld returns a world with randomness turned on, driven by seed.
+func genSeededWorld(seed int64) *World {
+	s := Settings{
+		NewPeep:           1,
+		MaxAge:            40,
+		MaxPeeps:          100,
+		RandomDeath:       0.01,
+		NewPeepMax:        50,
+		NewPeepModifier:   50,
+		Size:              &Size{10, 10, 0, -10, -10, 0},
+		SpawnAge:          5,
+		SpawnProbability:  0.5,
+		PeepViewDistance:  2,
+		PeepRememberTurns: 2,
+		MaxGenders:        4,
+		Seed:              seed,
+	}
+	w := NewWorld("Seeded", s, false)
+	w.SetDefaultHomebases()
+	return w
+}
+
+// turnHistory runs the world for the given number of turns and records the grid after each one
+func turnHistory(w *World, turns int) []string {
+	var history []string
+	for i := 0; i < turns; i++ {
+		w.NextTurn()
+		var b strings.Builder
+		w.Show(&b)
+		w.ShowGrid(&b)
+		history = append(history, b.String())
+	}
+	return history
+}
+
+func TestSeed(t *testing.T) {
+	enableMoves(t)
+
+	Convey("Worlds with the same seed have identical histories.", t, func() {
+		left := turnHistory(genSeededWorld(42), 200)
+		right := turnHistory(genSeededWorld(42), 200)
+		So(left, ShouldResemble, right)
+	})
+
+	Convey("Worlds with different seeds diverge.", t, func() {
+		left := turnHistory(genSeededWorld(42), 200)
+		right := turnHistory(genSeededWorld(43), 200)
+		So(left, ShouldNotResemble, right)
+	})
+
+	Convey("A seed is picked and recorded when none is given.", t, func() {
+		w := genWorld()
+		So(w.settings.Seed, ShouldNotEqual, 0)
+	})
+}